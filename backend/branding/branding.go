@@ -0,0 +1,216 @@
+/*
+ * File: branding.go
+ * File Created: Monday, 19th October 2026 7:51:34 am
+ * Last Modified: Monday, 19th October 2026 7:51:34 am
+ * Author: Akhil Datla
+ * Copyright © Akhil Datla 2023
+ */
+
+package branding
+
+import (
+	"bytes"
+	"errors"
+	"html"
+	"main/backend/dbmanager"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultOwner is the owner ID of the branding applied to every package.
+const DefaultOwner = "default"
+
+// Limits for branding values.
+const (
+	maxLogoSize       = 1 << 20
+	maxCustomCSSSize  = 64 << 10
+	maxTitleLength    = 100
+	maxFooterTextSize = 500
+)
+
+// ThemeVariants lists the colour variants shipped with the Hugo theme.
+var ThemeVariants = []string{"red", "blue", "green"}
+
+// pngSignature is the magic number every PNG file starts with.
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// Branding represents the branding overrides of an owner, such as a course.
+// Empty fields inherit the value from the underlying branding.
+type Branding struct {
+	ID           string `storm:"id"`
+	Logo         string
+	ThemeVariant string
+	Title        string
+	CustomCSS    string
+	FooterText   string
+}
+
+// Validate checks that the branding values can be used in a build.
+func (b Branding) Validate() error {
+	if b.ThemeVariant != "" && !isThemeVariant(b.ThemeVariant) {
+		return errors.New("invalid theme variant")
+	}
+
+	if len(b.Title) > maxTitleLength {
+		return errors.New("title too long")
+	}
+
+	if len(b.FooterText) > maxFooterTextSize {
+		return errors.New("footer text too long")
+	}
+
+	if len(b.CustomCSS) > maxCustomCSSSize {
+		return errors.New("custom css too large")
+	}
+
+	if b.Logo != "" {
+		// Check the logo is a reasonably sized PNG file
+		if !strings.EqualFold(filepath.Ext(b.Logo), ".png") {
+			return errors.New("logo must be a png file")
+		}
+
+		info, err := os.Stat(b.Logo)
+		if err != nil || info.IsDir() {
+			return errors.New("invalid logo filepath")
+		}
+
+		if info.Size() > maxLogoSize {
+			return errors.New("logo too large")
+		}
+
+		data, err := os.ReadFile(b.Logo)
+		if err != nil || !bytes.HasPrefix(data, pngSignature) {
+			return errors.New("logo must be a png file")
+		}
+	}
+
+	return nil
+}
+
+// SetBranding validates and saves the branding for the given owner ID.
+func SetBranding(ownerID string, b Branding) error {
+	b.ID = ownerID
+	err := b.Validate()
+	if err != nil {
+		return err
+	}
+
+	return dbmanager.Save(&b)
+}
+
+// GetBranding retrieves the branding for the given owner ID.
+func GetBranding(ownerID string) (Branding, error) {
+	var b Branding
+	err := dbmanager.Query("ID", ownerID, &b)
+	return b, err
+}
+
+// DeleteBranding deletes the branding for the given owner ID.
+func DeleteBranding(ownerID string) error {
+	b := &Branding{
+		ID: ownerID,
+	}
+	err := dbmanager.Delete(b)
+	return err
+}
+
+// Resolve merges the branding of the given owners in order, later owners overriding earlier ones.
+// Owners without branding are skipped.
+func Resolve(ownerIDs ...string) Branding {
+	var merged Branding
+	for _, ownerID := range ownerIDs {
+		b, err := GetBranding(ownerID)
+		if err != nil {
+			continue
+		}
+		merged = Merge(merged, b)
+	}
+	return merged
+}
+
+// Merge returns base with every non-empty field of override applied on top.
+func Merge(base, override Branding) Branding {
+	if override.Logo != "" {
+		base.Logo = override.Logo
+	}
+	if override.ThemeVariant != "" {
+		base.ThemeVariant = override.ThemeVariant
+	}
+	if override.Title != "" {
+		base.Title = override.Title
+	}
+	if override.CustomCSS != "" {
+		base.CustomCSS = override.CustomCSS
+	}
+	if override.FooterText != "" {
+		base.FooterText = override.FooterText
+	}
+	return base
+}
+
+// Apply writes the branding into the Hugo directory and returns the environment
+// variables that override the Hugo config for the build.
+func Apply(b Branding, hugoDir string) ([]string, error) {
+	// Validate again in case files changed since the branding was saved
+	err := b.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	env := make([]string, 0)
+	if b.Title != "" {
+		env = append(env, "HUGO_TITLE="+b.Title)
+	}
+	if b.ThemeVariant != "" {
+		env = append(env, "HUGO_PARAMS_THEMEVARIANT="+b.ThemeVariant)
+	}
+
+	// Replace the logo in the static directory
+	if b.Logo != "" {
+		logoBytes, err := os.ReadFile(b.Logo)
+		if err != nil {
+			return nil, err
+		}
+		err = os.WriteFile(filepath.Join(hugoDir, "static", "logo.png"), logoBytes, 0666)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Add the custom stylesheet and link it from the page header
+	if b.CustomCSS != "" {
+		os.MkdirAll(filepath.Join(hugoDir, "static", "css"), 0755)
+		err = os.WriteFile(filepath.Join(hugoDir, "static", "css", "branding.css"), []byte(b.CustomCSS), 0666)
+		if err != nil {
+			return nil, err
+		}
+
+		header := `<link href="{{ "css/branding.css" | relURL }}" rel="stylesheet">`
+		err = os.WriteFile(filepath.Join(hugoDir, "layouts", "partials", "custom-header.html"), []byte(header), 0666)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Replace the menu footer with the escaped footer text
+	if b.FooterText != "" {
+		footer := "<p>" + html.EscapeString(b.FooterText) + "</p>"
+		err = os.WriteFile(filepath.Join(hugoDir, "layouts", "partials", "menu-footer.html"), []byte(footer), 0666)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return env, nil
+}
+
+// isThemeVariant reports whether the variant is shipped with the Hugo theme.
+func isThemeVariant(variant string) bool {
+	for _, v := range ThemeVariants {
+		if v == variant {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,386 @@
+/*
+ * File: blobs.go
+ * File Created: Monday, 19th October 2026 8:35:43 am
+ * Last Modified: Monday, 19th October 2026 6:05:55 pm
+ * Author: Akhil Datla
+ * Copyright © Akhil Datla 2023
+ */
+
+package courses
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"main/backend/dbmanager"
+	"main/backend/security"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// BlobDir is the directory holding the content-addressed file bodies of course revisions. When
+// empty, blobs are kept in a "blobs" directory next to the database rather than the working directory.
+var BlobDir = ""
+
+// blobMutex keeps blobs from being collected while a snapshot that may refer to them is saved.
+var blobMutex sync.RWMutex
+
+// blobDir returns the directory of the blob store.
+func blobDir() string {
+	if BlobDir != "" {
+		return BlobDir
+	}
+	return filepath.Join(filepath.Dir(dbmanager.Path()), "blobs")
+}
+
+// PackageVersion is the format version of deduplicated packages. Version 1 is the plain file map
+// of earlier releases, which carries no version.
+const PackageVersion = 2
+
+// Package represents a website file map in which every unique file body is stored once.
+// Paths maps each file path to the hash of its body, with an empty hash for directories.
+type Package struct {
+	Version int
+	Paths   map[string]string
+	Blobs   map[string][]byte
+}
+
+// CourseRevision represents a snapshot of a course folder whose files are kept in the blob store.
+type CourseRevision struct {
+	ID        string `storm:"id"`
+	CourseID  string `storm:"index"`
+	Hash      string
+	Commit    string
+	CreatedAt time.Time
+	Files     map[string]string
+}
+
+// Deduplicate converts a file map into a package that stores each unique file body once.
+func Deduplicate(m map[string][]byte) Package {
+	p := Package{
+		Version: PackageVersion,
+		Paths:   make(map[string]string),
+		Blobs:   make(map[string][]byte),
+	}
+
+	for path, data := range m {
+		if len(data) == 0 {
+			p.Paths[path] = ""
+			continue
+		}
+
+		hash := security.Hash(data)
+		p.Paths[path] = hash
+		p.Blobs[hash] = data
+	}
+
+	return p
+}
+
+// Expand converts the package back into a file map.
+func (p Package) Expand() (map[string][]byte, error) {
+	m := make(map[string][]byte)
+
+	for path, hash := range p.Paths {
+		if hash == "" {
+			m[path] = []byte{}
+			continue
+		}
+
+		data, ok := p.Blobs[hash]
+		if !ok {
+			return nil, fmt.Errorf("missing blob for %s", path)
+		}
+		m[path] = data
+	}
+
+	return m, nil
+}
+
+// CompressAndEncryptPackage compresses and encrypts a deduplicated package using AES encryption with a given key.
+func CompressAndEncryptPackage(p Package, key string) ([]byte, error) {
+	// Encode the package
+	buf := new(bytes.Buffer)
+	err := gob.NewEncoder(buf).Encode(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode package: %w", err)
+	}
+
+	// Compress the encoded package
+	compressedPackage, err := compress(buf.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	// Encrypt the compressed package
+	return security.Encrypt(compressedPackage, security.DeriveKey(key))
+}
+
+// DecryptPackage decrypts and decompresses package data with the given key and returns its file map.
+// Both deduplicated packages and plain file maps are accepted, but not packages of a newer version.
+func DecryptPackage(data []byte, key string) (map[string][]byte, error) {
+	// Decrypt the package
+	compressed, err := security.Decrypt(data, security.DeriveKey(key))
+	if err != nil {
+		return nil, err
+	}
+
+	// Decompress the package
+	r, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Decode a deduplicated package, falling back to a plain file map
+	var p Package
+	if err := gob.NewDecoder(bytes.NewReader(encoded)).Decode(&p); err == nil && p.Paths != nil {
+		if p.Version > PackageVersion {
+			return nil, fmt.Errorf("unsupported package version %d", p.Version)
+		}
+		return p.Expand()
+	}
+
+	m := make(map[string][]byte)
+	err = gob.NewDecoder(bytes.NewReader(encoded)).Decode(&m)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode package: %w", err)
+	}
+
+	return m, nil
+}
+
+// blobPath returns the location of the blob with the given hash in the blob store.
+func blobPath(hash string) string {
+	return filepath.Join(blobDir(), hash[:2], hash)
+}
+
+// storeBlob writes the data to the blob store unless an identical body is already stored.
+// It returns the hash of the data.
+func storeBlob(data []byte) (string, error) {
+	hash := security.Hash(data)
+	path := blobPath(hash)
+
+	if _, err := os.Stat(path); err == nil {
+		return hash, nil
+	}
+
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return "", err
+	}
+
+	// Write to a temporary file first so a partial blob is never visible
+	tempPath := path + ".tmp"
+	err = os.WriteFile(tempPath, data, 0666)
+	if err != nil {
+		return "", err
+	}
+
+	return hash, os.Rename(tempPath, path)
+}
+
+// ReadBlob reads the blob with the given hash from the blob store.
+func ReadBlob(hash string) ([]byte, error) {
+	if len(hash) < 2 || strings.ContainsAny(hash, `/\.`) {
+		return nil, fmt.Errorf("invalid blob hash")
+	}
+
+	return os.ReadFile(blobPath(hash))
+}
+
+// SnapshotCourse stores the current contents of the course folder as a new revision. If the
+// contents did not change since the latest revision, the latest revision is returned instead.
+func SnapshotCourse(id string) (CourseRevision, error) {
+	course, err := GetCourse(id)
+	if err != nil {
+		return CourseRevision{}, fmt.Errorf("invalid course id")
+	}
+
+	dir, commit, cleanup, err := sourceDir(course)
+	if err != nil {
+		return CourseRevision{}, err
+	}
+	defer cleanup()
+
+	blobMutex.RLock()
+	defer blobMutex.RUnlock()
+
+	// Store each file body in the blob store
+	files := make(map[string]string)
+	_, err = walkCourse(dir, func(relativePath, realPath string, isDir bool) error {
+		if isDir {
+			files[relativePath] = ""
+			return nil
+		}
+
+		data, err := os.ReadFile(realPath)
+		if err != nil {
+			return err
+		}
+
+		hash, err := storeBlob(data)
+		if err != nil {
+			return err
+		}
+		files[relativePath] = hash
+
+		return nil
+	})
+	if err != nil {
+		return CourseRevision{}, err
+	}
+
+	revision := CourseRevision{
+		ID:        uuid.NewV4().String(),
+		CourseID:  id,
+		Hash:      hashFiles(files),
+		Commit:    commit,
+		CreatedAt: time.Now(),
+		Files:     files,
+	}
+
+	// Reuse the latest revision if nothing changed
+	revisions, _ := GetCourseRevisions(id)
+	if len(revisions) > 0 && revisions[len(revisions)-1].Hash == revision.Hash {
+		return revisions[len(revisions)-1], nil
+	}
+
+	err = dbmanager.Save(&revision)
+	return revision, err
+}
+
+// hashFiles computes a single hash over the paths and file hashes of a revision.
+func hashFiles(files map[string]string) string {
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var b strings.Builder
+	for _, path := range paths {
+		b.WriteString(path + "\x00" + files[path] + "\n")
+	}
+
+	return security.Hash([]byte(b.String()))
+}
+
+// GetCourseRevisions retrieves the revisions of the course with the given ID, oldest first.
+func GetCourseRevisions(courseID string) ([]CourseRevision, error) {
+	var revisions []CourseRevision
+	err := dbmanager.GroupQuery("CourseID", courseID, &revisions)
+
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisions[i].CreatedAt.Before(revisions[j].CreatedAt)
+	})
+
+	return revisions, err
+}
+
+// deleteRevisions deletes the revisions of the course with the given ID and removes the blobs that
+// no remaining revision of any course refers to.
+func deleteRevisions(courseID string) error {
+	blobMutex.Lock()
+	defer blobMutex.Unlock()
+
+	revisions, err := GetCourseRevisions(courseID)
+	if dbmanager.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	candidates := make(map[string]bool)
+	for i := range revisions {
+		err = dbmanager.Delete(&revisions[i])
+		if err != nil {
+			return err
+		}
+		for _, hash := range revisions[i].Files {
+			if hash != "" {
+				candidates[hash] = true
+			}
+		}
+	}
+
+	// Keep the blobs still referenced by other revisions
+	var remaining []CourseRevision
+	err = dbmanager.QueryAll(&remaining)
+	if err != nil && !dbmanager.IsNotFound(err) {
+		return err
+	}
+	for _, revision := range remaining {
+		for _, hash := range revision.Files {
+			delete(candidates, hash)
+		}
+	}
+
+	for hash := range candidates {
+		err = os.Remove(blobPath(hash))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetRevision retrieves a course revision by its ID.
+func GetRevision(revisionID string) (CourseRevision, error) {
+	var revision CourseRevision
+	err := dbmanager.Query("ID", revisionID, &revision)
+	if err != nil {
+		return CourseRevision{}, fmt.Errorf("invalid revision id")
+	}
+	return revision, nil
+}
+
+// RestoreRevision writes the files of the revision with the given ID into dir.
+func RestoreRevision(revisionID, dir string) error {
+	revision, err := GetRevision(revisionID)
+	if err != nil {
+		return err
+	}
+
+	for relativePath, hash := range revision.Files {
+		target := filepath.Join(dir, filepath.FromSlash(relativePath))
+
+		if hash == "" {
+			err = os.MkdirAll(target, 0755)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		data, err := ReadBlob(hash)
+		if err != nil {
+			return err
+		}
+
+		err = os.MkdirAll(filepath.Dir(target), 0755)
+		if err != nil {
+			return err
+		}
+
+		err = os.WriteFile(target, data, 0666)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
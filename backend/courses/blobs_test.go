@@ -0,0 +1,231 @@
+/*
+ * File: blobs_test.go
+ * File Created: Monday, 19th October 2026 6:05:55 pm
+ * Last Modified: Monday, 19th October 2026 6:05:55 pm
+ * Author: Akhil Datla
+ * Copyright © Akhil Datla 2023
+ */
+
+package courses
+
+import (
+	"bytes"
+	"encoding/gob"
+	"main/backend/dbmanager"
+	"main/backend/security"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// sameFiles reports whether both file maps hold the same paths and bodies.
+func sameFiles(a, b map[string][]byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for path, data := range a {
+		other, ok := b[path]
+		if !ok || !bytes.Equal(data, other) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestPackageRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string][]byte
+		blobs int
+	}{
+		{"empty", map[string][]byte{}, 0},
+		{"directories only", map[string][]byte{"course": {}, "course/lessons": {}}, 0},
+		{"unique files", map[string][]byte{"index.html": []byte("<h1>Home</h1>"), "style.css": []byte("body{}")}, 2},
+		{"shared bodies", map[string][]byte{
+			"course-a/logo.png":   []byte("logo"),
+			"course-b/logo.png":   []byte("logo"),
+			"course-b/index.html": []byte("<h1>B</h1>"),
+			"course-b":            {},
+		}, 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := Deduplicate(test.files)
+			if len(p.Blobs) != test.blobs {
+				t.Errorf("Deduplicate stored %d blobs, want %d", len(p.Blobs), test.blobs)
+			}
+
+			data, err := CompressAndEncryptPackage(p, "HW-1")
+			if err != nil {
+				t.Fatalf("CompressAndEncryptPackage failed: %v", err)
+			}
+
+			got, err := DecryptPackage(data, "HW-1")
+			if err != nil {
+				t.Fatalf("DecryptPackage failed: %v", err)
+			}
+			if !sameFiles(got, test.files) {
+				t.Errorf("DecryptPackage = %v, want %v", got, test.files)
+			}
+
+			if _, err := DecryptPackage(data, "HW-2"); err == nil {
+				t.Errorf("DecryptPackage with another key succeeded, want an error")
+			}
+		})
+	}
+}
+
+// encryptGob encodes the value with gob, then compresses and encrypts it with the key.
+func encryptGob(t *testing.T, value interface{}, key string) []byte {
+	t.Helper()
+
+	buf := new(bytes.Buffer)
+	if err := gob.NewEncoder(buf).Encode(value); err != nil {
+		t.Fatalf("encoding: %v", err)
+	}
+	compressed, err := compress(buf.Bytes())
+	if err != nil {
+		t.Fatalf("compressing: %v", err)
+	}
+	data, err := security.Encrypt(compressed, security.DeriveKey(key))
+	if err != nil {
+		t.Fatalf("encrypting: %v", err)
+	}
+	return data
+}
+
+func TestDecryptPackageVersions(t *testing.T) {
+	files := map[string][]byte{"index.html": []byte("<h1>Home</h1>"), "lessons": {}}
+
+	newer := Deduplicate(files)
+	newer.Version = PackageVersion + 1
+
+	missing := Deduplicate(files)
+	missing.Blobs = map[string][]byte{}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr bool
+	}{
+		{"plain file map of earlier releases", encryptGob(t, files, "HW-1"), false},
+		{"deduplicated package", encryptGob(t, Deduplicate(files), "HW-1"), false},
+		{"newer version", encryptGob(t, newer, "HW-1"), true},
+		{"missing blob", encryptGob(t, missing, "HW-1"), true},
+		{"neither format", encryptGob(t, []string{"index.html"}, "HW-1"), true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := DecryptPackage(test.data, "HW-1")
+			if (err != nil) != test.wantErr {
+				t.Fatalf("DecryptPackage = %v, want error %v", err, test.wantErr)
+			}
+			if !test.wantErr && !sameFiles(got, files) {
+				t.Errorf("DecryptPackage = %v, want %v", got, files)
+			}
+		})
+	}
+}
+
+// testCourse saves a course reading from a new folder holding the files.
+func testCourse(t *testing.T, id string, files map[string]string) Course {
+	t.Helper()
+
+	course := Course{ID: id, Name: id, Filepath: t.TempDir()}
+	writeFiles(t, course.Filepath, files)
+	if err := dbmanager.Save(&course); err != nil {
+		t.Fatalf("saving course: %v", err)
+	}
+	return course
+}
+
+func TestSnapshotAndRestoreRevision(t *testing.T) {
+	openTestDatabase(t)
+	BlobDir = t.TempDir()
+	t.Cleanup(func() { BlobDir = "" })
+
+	course := testCourse(t, "snapshot", map[string]string{
+		"index.md":          "# Welcome",
+		"lessons/a.md":      "# Lesson",
+		"lessons/copy.md":   "# Lesson",
+		"lessons/notes.swp": "ignored",
+	})
+
+	first, err := SnapshotCourse(course.ID)
+	if err != nil {
+		t.Fatalf("SnapshotCourse failed: %v", err)
+	}
+	if _, ok := first.Files["lessons/notes.swp"]; ok {
+		t.Errorf("revision holds the ignored file lessons/notes.swp")
+	}
+	if first.Files["lessons/a.md"] != first.Files["lessons/copy.md"] {
+		t.Errorf("identical files are stored as different blobs")
+	}
+
+	// An unchanged folder reuses the latest revision
+	again, err := SnapshotCourse(course.ID)
+	if err != nil || again.ID != first.ID {
+		t.Errorf("SnapshotCourse of an unchanged folder = %q, %v, want revision %q", again.ID, err, first.ID)
+	}
+
+	writeFiles(t, course.Filepath, map[string]string{"index.md": "# Welcome back"})
+	second, err := SnapshotCourse(course.ID)
+	if err != nil || second.ID == first.ID {
+		t.Fatalf("SnapshotCourse of a changed folder = %q, %v, want a new revision", second.ID, err)
+	}
+
+	// The earlier revision is restored as it was
+	dir := t.TempDir()
+	if err := RestoreRevision(first.ID, dir); err != nil {
+		t.Fatalf("RestoreRevision failed: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "index.md"))
+	if err != nil || string(data) != "# Welcome" {
+		t.Errorf("restored index.md = %q, %v, want %q", data, err, "# Welcome")
+	}
+}
+
+func TestDeleteCourseCollectsBlobs(t *testing.T) {
+	openTestDatabase(t)
+	BlobDir = t.TempDir()
+	t.Cleanup(func() { BlobDir = "" })
+
+	deleted := testCourse(t, "deleted", map[string]string{"own.md": "only here", "shared.md": "in both"})
+	kept := testCourse(t, "kept", map[string]string{"shared.md": "in both"})
+
+	deletedRevision, err := SnapshotCourse(deleted.ID)
+	if err != nil {
+		t.Fatalf("SnapshotCourse failed: %v", err)
+	}
+	if _, err := SnapshotCourse(kept.ID); err != nil {
+		t.Fatalf("SnapshotCourse failed: %v", err)
+	}
+
+	if err := DeleteCourse(deleted.ID); err != nil {
+		t.Fatalf("DeleteCourse failed: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		hash string
+		want bool
+	}{
+		{"blob of the deleted course only", deletedRevision.Files["own.md"], false},
+		{"blob shared with another course", deletedRevision.Files["shared.md"], true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ReadBlob(test.hash)
+			if (err == nil) != test.want {
+				t.Errorf("ReadBlob after DeleteCourse = %v, want blob kept %v", err, test.want)
+			}
+		})
+	}
+
+	if _, err := GetRevision(deletedRevision.ID); err == nil {
+		t.Errorf("revision of the deleted course still exists")
+	}
+}
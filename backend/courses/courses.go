@@ -1,7 +1,7 @@
 /*
  * File: courses.go
  * File Created: Sunday, 11th June 2023 9:57:15 pm
- * Last Modified: Friday, 23rd June 2023 1:19:57 pm
+ * Last Modified: Monday, 19th October 2026 6:05:55 pm
  * Author: Akhil Datla
  * Copyright © Akhil Datla 2023
  */
@@ -11,15 +11,22 @@ package courses
 import (
 	"bytes"
 	"compress/gzip"
+	"encoding/csv"
 	"encoding/gob"
 	"fmt"
 	"io/ioutil"
+	"main/backend/branding"
 	"main/backend/dbmanager"
+	"main/backend/organizations"
+	"main/backend/quizzes"
+	"main/backend/search"
 	"main/backend/security"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
 	cp "github.com/otiai10/copy"
 	uuid "github.com/satori/go.uuid"
@@ -27,30 +34,93 @@ import (
 
 // Course represents a course object.
 type Course struct {
-	ID       string `storm:"id"`
-	Name     string `storm:"unique"`
-	Filepath string
+	ID             string `storm:"id"`
+	Name           string `storm:"index"`
+	OrganizationID string `storm:"index"`
+	Filepath       string
+	PublishAt      time.Time
+	UnpublishAt    time.Time
+	GitRef         string
+	Commit         string
+	ContentHash    string
+	UpdatedAt      time.Time
 }
 
-// CreateCourse creates a new course with the given name and filepath.
+// IsPublished reports whether the course may be delivered at the given time.
+// A zero PublishAt or UnpublishAt leaves that side of the window open.
+func (c Course) IsPublished(now time.Time) bool {
+	if !c.PublishAt.IsZero() && now.Before(c.PublishAt) {
+		return false
+	}
+	if !c.UnpublishAt.IsZero() && !now.Before(c.UnpublishAt) {
+		return false
+	}
+	return true
+}
+
+// CreateCourse creates a new course of the default organization with the given name and filepath.
 func CreateCourse(name, filepath string) (string, error) {
+	return CreateOrganizationCourse(organizations.Default, name, filepath)
+}
+
+// CreateOrganizationCourse creates a new course of the organization with the given name and filepath.
+func CreateOrganizationCourse(organizationID, name, filepath string) (string, error) {
 	// Check if the filepath exists
 	if _, err := os.Stat(filepath); os.IsNotExist(err) {
 		return "", fmt.Errorf("invalid filepath")
 	}
 
+	// Check that every quiz in the course is valid
+	if err := quizzes.ValidateDir(filepath); err != nil {
+		return "", fmt.Errorf("invalid quiz: %w", err)
+	}
+
+	if err := checkName(organizationID, "", name); err != nil {
+		return "", err
+	}
+
 	// Create a new course object
 	course := &Course{
-		ID:       uuid.NewV4().String(),
-		Name:     name,
-		Filepath: filepath,
+		ID:             uuid.NewV4().String(),
+		Name:           name,
+		OrganizationID: organizationID,
+		Filepath:       filepath,
+		UpdatedAt:      time.Now(),
 	}
 
 	// Save the course to the database
 	err := dbmanager.Save(course)
+	if err != nil {
+		return "", err
+	}
+
+	// Store the first revision of the course files, dropping the course if that fails
+	_, err = SnapshotCourse(course.ID)
+	if err != nil {
+		dbmanager.Delete(course)
+		return "", err
+	}
+
+	// Add the course to the search index
+	err = indexCourse(*course)
 	return course.ID, err
 }
 
+// checkName checks that no other course of the organization than the one with the given ID has the name.
+func checkName(organizationID, id, name string) error {
+	var existing Course
+	err := dbmanager.OrganizationQuery(organizationID, "Name", name, &existing)
+	if err == nil && existing.ID != id {
+		return fmt.Errorf("course name already in use")
+	}
+	return nil
+}
+
+// SectionName returns the name of the Hugo content section holding the course.
+func SectionName(course Course) string {
+	return strings.ReplaceAll(course.Name, " ", "-")
+}
+
 // GetCourse retrieves a course by its ID.
 func GetCourse(id string) (Course, error) {
 	var course Course
@@ -65,6 +135,16 @@ func GetAllCourses() ([]Course, error) {
 	return courses, err
 }
 
+// GetOrganizationCourses retrieves the courses of the organization with the given ID.
+func GetOrganizationCourses(organizationID string) ([]Course, error) {
+	var courses []Course
+	err := dbmanager.OrganizationQueryAll(organizationID, &courses)
+	if dbmanager.IsNotFound(err) {
+		return make([]Course, 0), nil
+	}
+	return courses, err
+}
+
 // UpdateCourse updates a course with the given ID, name, and filepath.
 func UpdateCourse(id, name, filepath string) error {
 	// Check if the filepath exists
@@ -72,6 +152,19 @@ func UpdateCourse(id, name, filepath string) error {
 		return fmt.Errorf("invalid filepath")
 	}
 
+	// Check that every quiz in the course is valid
+	if err := quizzes.ValidateDir(filepath); err != nil {
+		return fmt.Errorf("invalid quiz: %w", err)
+	}
+
+	previous, err := GetCourse(id)
+	if err != nil {
+		return fmt.Errorf("invalid course id")
+	}
+	if err := checkName(previous.OrganizationID, id, name); err != nil {
+		return err
+	}
+
 	// Create a new course object with updated values
 	course := &Course{
 		ID:       id,
@@ -80,68 +173,379 @@ func UpdateCourse(id, name, filepath string) error {
 	}
 
 	// Update the course in the database
-	err := dbmanager.Update(course)
+	err = dbmanager.Update(course)
+	if err != nil {
+		return err
+	}
+
+	// Store a new revision if the course files changed, restoring the course if that fails
+	_, err = SnapshotCourse(id)
+	if err != nil {
+		dbmanager.Save(&previous)
+		return err
+	}
+
+	// Refresh the course in the search index
+	updated, err := GetCourse(id)
+	if err != nil {
+		return err
+	}
+	return indexCourse(updated)
+}
+
+// SetPublicationWindow sets the time window in which the course with the given ID is delivered.
+// Zero times leave that side of the window open.
+func SetPublicationWindow(id string, publishAt, unpublishAt time.Time) error {
+	if !publishAt.IsZero() && !unpublishAt.IsZero() && !unpublishAt.After(publishAt) {
+		return fmt.Errorf("unpublish time must be after publish time")
+	}
+
+	course, err := GetCourse(id)
+	if err != nil {
+		return fmt.Errorf("invalid course id")
+	}
+
+	course.PublishAt = publishAt
+	course.UnpublishAt = unpublishAt
+
+	// Save the whole course so that cleared times are stored as well
+	err = dbmanager.Save(&course)
 	return err
 }
 
+// PartitionPublished splits the course IDs into courses that are published at the given time
+// and courses that are embargoed. Unknown course IDs are treated as embargoed.
+func PartitionPublished(courseIDs []string, now time.Time) ([]string, []string) {
+	published := make([]string, 0)
+	embargoed := make([]string, 0)
+
+	for _, id := range courseIDs {
+		course, err := GetCourse(id)
+		if err != nil || !course.IsPublished(now) {
+			embargoed = append(embargoed, id)
+			continue
+		}
+		published = append(published, id)
+	}
+
+	return published, embargoed
+}
+
 // DeleteCourse deletes a course with the given ID.
 func DeleteCourse(id string) error {
 	course := &Course{
 		ID: id,
 	}
 	err := dbmanager.Delete(course)
-	return err
+	if err != nil {
+		return err
+	}
+
+	// Remove the course from the search index
+	search.RemoveCourse(id)
+
+	// Remove any branding and revisions belonging to the course, along with the blobs no other
+	// revision refers to
+	branding.DeleteBranding(id)
+	return deleteRevisions(id)
+}
+
+// SetCourseBranding validates and saves the branding overrides for the course with the given ID.
+func SetCourseBranding(id string, b branding.Branding) error {
+	_, err := GetCourse(id)
+	if err != nil {
+		return fmt.Errorf("invalid course id")
+	}
+
+	return branding.SetBranding(id, b)
+}
+
+// BuildReport describes what went into a website build. Commits maps the IDs of git-backed
+// courses to the commit they were built from.
+type BuildReport struct {
+	Excluded []string          `json:"excluded"`
+	Commits  map[string]string `json:"commits"`
+	Sections map[string]string `json:"sections"`
+}
+
+// copyCourseSource copies the course source into dst, from a clean checkout of the pinned commit
+// for git-backed courses. It returns the excluded paths and the commit that was built.
+func copyCourseSource(course Course, dst string) ([]string, string, error) {
+	dir, commit, cleanup, err := sourceDir(course)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", course.Name, err)
+	}
+	defer cleanup()
+
+	excluded, err := CopyCourse(dir, dst)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Record the commit the course was last built from
+	if commit != "" && commit != course.Commit {
+		err = recordCommit(course.ID, commit)
+	}
+
+	return excluded, commit, err
+}
+
+// indexCourse adds the Markdown pages and asset files of the course to the administrator search index.
+func indexCourse(course Course) error {
+	pages := make([]search.Page, 0)
+
+	dir, _, cleanup, err := sourceDir(course)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	_, err = walkCourse(dir, func(relativePath, realPath string, isDir bool) error {
+		if isDir {
+			return nil
+		}
+
+		if !strings.EqualFold(filepath.Ext(relativePath), ".md") {
+			pages = append(pages, search.AssetPage(relativePath))
+			return nil
+		}
+
+		data, err := os.ReadFile(realPath)
+		if err != nil {
+			return err
+		}
+		pages = append(pages, search.MarkdownPage(relativePath, data))
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	search.IndexCourse(course.ID, course.Name, pages)
+	return nil
 }
 
-// GenerateWebsite generates a website using Hugo for the specified hardware ID and course IDs.
-func GenerateWebsite(hardwareID string, courseIDs []string) string {
-	filePaths := make([]string, 0)
-	courseNames := make([]string, 0)
+// IndexAllCourses rebuilds the administrator search index for every course.
+func IndexAllCourses() error {
+	allCourses, err := GetAllCourses()
+	if err != nil {
+		return err
+	}
+
+	for _, course := range allCourses {
+		err = indexCourse(course)
+		if err != nil {
+			return fmt.Errorf("%s: %w", course.Name, err)
+		}
+	}
+
+	return nil
+}
 
-	// Get filepaths and course names for the specified course IDs
+// BuildWebsite builds the website using Hugo for the specified course IDs and returns
+// a file map of the generated public directory together with a build report.
+func BuildWebsite(courseIDs []string) (map[string][]byte, BuildReport, error) {
+	return buildWebsite(courseIDs, nil)
+}
+
+// BuildPortableWebsite works like BuildWebsite but generates relative links and a file per page,
+// so the website can be served from any directory without a web server resolving index pages.
+func BuildPortableWebsite(courseIDs []string) (map[string][]byte, BuildReport, error) {
+	return buildWebsite(courseIDs, []string{"HUGO_RELATIVEURLS=true", "HUGO_UGLYURLS=true"})
+}
+
+// buildWebsite builds the website with the given extra environment variables for Hugo.
+func buildWebsite(courseIDs []string, env []string) (map[string][]byte, BuildReport, error) {
+	report := BuildReport{
+		Excluded: make([]string, 0),
+		Commits:  make(map[string]string),
+		Sections: make(map[string]string),
+	}
+	buildCourses := make([]Course, 0)
+
+	// Get the specified courses, skipping unpublished courses
+	now := time.Now()
+	organizationIDs := make(map[string]bool)
 	for _, id := range courseIDs {
 		course, _ := GetCourse(id)
-		filePaths = append(filePaths, course.Filepath)
-		courseNames = append(courseNames, strings.ReplaceAll(course.Name, " ", "-"))
+		if !course.IsPublished(now) {
+			continue
+		}
+		buildCourses = append(buildCourses, course)
+		organizationIDs[course.OrganizationID] = true
+	}
+
+	// Packages with the courses of a single organization get its homepage and branding
+	organizationID := organizations.Default
+	if len(organizationIDs) == 1 {
+		for id := range organizationIDs {
+			organizationID = id
+		}
 	}
 
 	// Create a temporary directory for Hugo
-	tempHugoDir, _ := ioutil.TempDir("", "learnado")
+	tempHugoDir, err := ioutil.TempDir("", "learnado")
+	if err != nil {
+		return nil, report, err
+	}
+
+	// Remove the temporary Hugo directory
+	defer os.RemoveAll(tempHugoDir)
 
 	// Copy the Hugo directory to the temporary directory
 	cp.Copy(filepath.Join(filepath.Dir(""), "hugo"), tempHugoDir)
 
-	// Copy course files to the temporary Hugo content directory
-	for i, filePath := range filePaths {
-		os.MkdirAll(filepath.Join(tempHugoDir, "content", courseNames[i]), 0755)
-		cp.Copy(filePath, filepath.Join(tempHugoDir, "content", courseNames[i]))
+	// Copy course files to the temporary Hugo content directory, leaving out ignored files
+	for _, course := range buildCourses {
+		excluded, commit, err := copyCourseSource(course, filepath.Join(tempHugoDir, "content", SectionName(course)))
+		if err != nil {
+			return nil, report, err
+		}
+		for _, path := range excluded {
+			report.Excluded = append(report.Excluded, SectionName(course)+"/"+path)
+		}
+		if commit != "" {
+			report.Commits[course.ID] = commit
+		}
+	}
+
+	// Render quiz files into interactive pages without readable answer keys
+	err = quizzes.RenderDir(filepath.Join(tempHugoDir, "content"))
+	if err != nil {
+		return nil, report, err
 	}
 
 	// Copy homepage content to the temporary Hugo content directory
-	homepageBytes, _ := os.ReadFile("homepage.md")
+	homepageBytes, _ := os.ReadFile(organizations.Homepage(organizationID))
 	os.WriteFile(filepath.Join(tempHugoDir, "content", "_index.md"), homepageBytes, 0666)
 
+	// Add the offline search page
+	searchPage := "---\ntitle: \"Search\"\nweight: 10000\n---\n\n{{< search >}}\n"
+	os.WriteFile(filepath.Join(tempHugoDir, "content", search.SearchSection+".md"), []byte(searchPage), 0666)
+
+	// Apply the default branding, overridden by the organization branding and by the course branding
+	// for single-course packages
+	owners := []string{branding.DefaultOwner}
+	if organizationID != organizations.Default {
+		owners = append(owners, organizations.BrandingOwner(organizationID))
+	}
+	if len(courseIDs) == 1 {
+		owners = append(owners, courseIDs[0])
+	}
+	brandingEnv, err := branding.Apply(branding.Resolve(owners...), tempHugoDir)
+	if err != nil {
+		return nil, report, err
+	}
+
 	// Build the Hugo website
 	buildCmd := exec.Command("hugo")
 	buildCmd.Dir = tempHugoDir
+	buildCmd.Env = append(append(os.Environ(), brandingEnv...), env...)
 	buildCmd.Run()
 
-	// Generate a unique filename for the compressed and encrypted website data
-	gobFileName := uuid.NewV4().String() + ".gob"
+	// Find where Hugo put the section of every course
+	report.Sections = sectionPaths(tempHugoDir, buildCourses, buildCmd.Env)
+
+	// Create a file map of the Hugo public directory. The ignore rules were applied to the course
+	// sources already and do not apply to the generated site.
+	m, err := FileMapFunction(filepath.Join(tempHugoDir, "public"))
+	if err != nil {
+		return nil, report, err
+	}
+
+	// Generate the offline search index from the built pages
+	searchIndex, err := search.BuildOfflineIndex(m).Script()
+	if err != nil {
+		return nil, report, err
+	}
+	m[search.IndexFileName] = searchIndex
 
-	// Create a file map of the Hugo public directory
-	m, _ := FileMapFunction(filepath.Join(tempHugoDir, "public"))
+	return m, report, nil
+}
 
-	// Compress and encrypt the file map
-	compressedEncryptedMap, _ := CompressAndEncryptMap(m, hardwareID)
+// sectionPaths asks Hugo for the permalinks of the pages in hugoDir and returns the folder of the
+// built website holding the section of every course, keyed by course ID. Hugo may rename sections,
+// for example by lowercasing them, so the folder can differ from the section name.
+func sectionPaths(hugoDir string, buildCourses []Course, env []string) map[string]string {
+	paths := make(map[string]string)
 
-	// Write the compressed and encrypted data to the gob file
-	os.WriteFile(gobFileName, compressedEncryptedMap, 0666)
+	listCmd := exec.Command("hugo", "list", "all")
+	listCmd.Dir = hugoDir
+	listCmd.Env = env
+	output, err := listCmd.Output()
+	if err != nil {
+		return paths
+	}
 
-	// Remove the temporary Hugo directory
-	os.RemoveAll(tempHugoDir)
+	records, err := csv.NewReader(bytes.NewReader(output)).ReadAll()
+	if err != nil || len(records) == 0 {
+		return paths
+	}
 
-	return gobFileName
+	// Columns differ between Hugo versions, so look them up in the header
+	pathColumn, permalinkColumn := -1, -1
+	for i, name := range records[0] {
+		switch name {
+		case "path":
+			pathColumn = i
+		case "permalink":
+			permalinkColumn = i
+		}
+	}
+	if pathColumn < 0 || permalinkColumn < 0 {
+		return paths
+	}
+
+	sections := make(map[string]string)
+	for _, course := range buildCourses {
+		sections[SectionName(course)] = course.ID
+	}
+
+	for _, record := range records[1:] {
+		if len(record) <= pathColumn || len(record) <= permalinkColumn {
+			continue
+		}
+
+		// Source paths look like content/<section>/page.md
+		source := strings.Split(filepath.ToSlash(record[pathColumn]), "/")
+		if len(source) < 3 || source[0] != "content" {
+			continue
+		}
+		courseID, ok := sections[source[1]]
+		if !ok || paths[courseID] != "" {
+			continue
+		}
+
+		permalink, err := url.Parse(record[permalinkColumn])
+		if err != nil {
+			continue
+		}
+		folder := strings.SplitN(strings.TrimPrefix(permalink.Path, "/"), "/", 2)[0]
+		folder = strings.TrimSuffix(folder, ".html")
+		if folder != "" {
+			paths[courseID] = folder
+		}
+	}
+
+	return paths
+}
+
+// PackageWebsite compresses and encrypts the file map for the hardware ID and writes it
+// to a uniquely named gob file inside dir. It returns the path of the gob file.
+func PackageWebsite(m map[string][]byte, hardwareID, dir string) (string, error) {
+	// Generate a unique filename for the compressed and encrypted website data
+	gobFileName := filepath.Join(dir, uuid.NewV4().String()+".gob")
+
+	// Deduplicate, compress and encrypt the file map
+	compressedEncryptedPackage, err := CompressAndEncryptPackage(Deduplicate(m), hardwareID)
+	if err != nil {
+		return "", err
+	}
+
+	// Write the compressed and encrypted data to the gob file
+	err = os.WriteFile(gobFileName, compressedEncryptedPackage, 0666)
+	return gobFileName, err
 }
 
 // FileMapFunction traverses a directory structure and creates a map with
@@ -0,0 +1,228 @@
+/*
+ * File: git.go
+ * File Created: Monday, 19th October 2026 9:30:57 am
+ * Last Modified: Monday, 19th October 2026 2:04:30 pm
+ * Author: Akhil Datla
+ * Copyright © Akhil Datla 2023
+ */
+
+package courses
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"main/backend/dbmanager"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// runGit runs git with the given arguments inside the repository and returns its trimmed output.
+func runGit(repo string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.Command("git", append([]string{"-C", repo}, args...)...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(stderr.String()))
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// ResolveCommit resolves a branch, tag or commit in the git repository to a full commit hash.
+func ResolveCommit(repo, ref string) (string, error) {
+	if strings.HasPrefix(ref, "-") {
+		return "", fmt.Errorf("invalid git ref")
+	}
+
+	return runGit(repo, "rev-parse", "--verify", "--end-of-options", ref+"^{commit}")
+}
+
+// checkoutCommit extracts a clean copy of the commit into a new temporary directory, leaving the
+// working directory of the repository untouched. The caller removes the directory.
+func checkoutCommit(repo, commit string) (string, error) {
+	dir, err := ioutil.TempDir("", "learnado-git")
+	if err != nil {
+		return "", err
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", "-C", repo, "archive", "--format=tar", commit)
+	cmd.Stderr = &stderr
+	archive, err := cmd.StdoutPipe()
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+
+	err = cmd.Start()
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+
+	extractErr := extractTar(archive, dir)
+	io.Copy(io.Discard, archive)
+	waitErr := cmd.Wait()
+
+	if extractErr != nil || waitErr != nil {
+		os.RemoveAll(dir)
+		if extractErr != nil {
+			return "", extractErr
+		}
+		return "", fmt.Errorf("git archive: %s", strings.TrimSpace(stderr.String()))
+	}
+
+	return dir, nil
+}
+
+// extractTar extracts the directories, regular files and symbolic links of a tar stream into dir.
+// Symbolic links are kept as they are so that the symlink policy applies when the copy is walked,
+// but links leading out of dir and entries written through links are refused.
+func extractTar(r io.Reader, dir string) error {
+	realDir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return err
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(realDir, filepath.FromSlash(header.Name))
+		if !withinRoot(realDir, target) || !realWithinRoot(realDir, target) {
+			return fmt.Errorf("unsafe path in archive: %s", header.Name)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(target, 0755)
+		case tar.TypeReg:
+			err = os.MkdirAll(filepath.Dir(target), 0755)
+			if err == nil {
+				var data []byte
+				data, err = io.ReadAll(tr)
+				if err == nil {
+					err = os.WriteFile(target, data, 0666)
+				}
+			}
+		case tar.TypeSymlink:
+			err = os.MkdirAll(filepath.Dir(target), 0755)
+			if err == nil {
+				err = extractSymlink(realDir, target, header.Linkname)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// realWithinRoot reports whether the path stays inside root once the symbolic links of the part
+// of it that already exists are resolved. An existing symbolic link at the path itself does not
+// count as inside, since writing to it would follow the link.
+func realWithinRoot(root, path string) bool {
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		return false
+	}
+
+	existing := path
+	for withinRoot(root, existing) {
+		real, err := filepath.EvalSymlinks(existing)
+		if err == nil {
+			return withinRoot(root, real)
+		}
+		if _, err := os.Lstat(existing); err == nil {
+			// A dangling link
+			return false
+		}
+		existing = filepath.Dir(existing)
+	}
+	return false
+}
+
+// extractSymlink creates a symbolic link at target to linkname, refusing links that resolve to a
+// path outside root.
+func extractSymlink(root, target, linkname string) error {
+	destination := linkname
+	if !filepath.IsAbs(destination) {
+		destination = filepath.Join(filepath.Dir(target), destination)
+	}
+	if !withinRoot(root, destination) {
+		return fmt.Errorf("unsafe symlink in archive: %s", linkname)
+	}
+
+	err := os.Symlink(linkname, target)
+	if err != nil {
+		return err
+	}
+
+	// Links through other links may still leave root once resolved
+	if real, err := filepath.EvalSymlinks(target); err == nil && !withinRoot(root, real) {
+		os.Remove(target)
+		return fmt.Errorf("unsafe symlink in archive: %s", linkname)
+	}
+	return nil
+}
+
+// sourceDir returns the directory to read the course files from and the commit it was taken
+// from, if the course is backed by git. The returned cleanup function removes any checkout.
+func sourceDir(course Course) (string, string, func(), error) {
+	if course.GitRef == "" {
+		return course.Filepath, "", func() {}, nil
+	}
+
+	commit, err := ResolveCommit(course.Filepath, course.GitRef)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	dir, err := checkoutCommit(course.Filepath, commit)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	return dir, commit, func() { os.RemoveAll(dir) }, nil
+}
+
+// SetGitSource makes the course with the given ID build from the branch, tag or commit ref of the
+// git repository at its filepath. An empty ref makes the course build from its folder again.
+func SetGitSource(id, ref string) error {
+	course, err := GetCourse(id)
+	if err != nil {
+		return fmt.Errorf("invalid course id")
+	}
+
+	course.GitRef = ref
+	course.Commit = ""
+
+	// Check that the ref can be resolved in the repository
+	if ref != "" {
+		course.Commit, err = ResolveCommit(course.Filepath, ref)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Save the whole course so that a cleared ref is stored as well
+	return dbmanager.Save(&course)
+}
+
+// recordCommit stores the commit a course was last built from.
+func recordCommit(id, commit string) error {
+	return dbmanager.Update(&Course{ID: id, Commit: commit})
+}
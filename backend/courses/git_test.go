@@ -0,0 +1,153 @@
+/*
+ * File: git_test.go
+ * File Created: Monday, 19th October 2026 6:38:11 pm
+ * Last Modified: Monday, 19th October 2026 6:38:11 pm
+ * Author: Akhil Datla
+ * Copyright © Akhil Datla 2023
+ */
+
+package courses
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// tarEntry describes a single entry of a test archive.
+type tarEntry struct {
+	name     string
+	typeflag byte
+	linkname string
+	body     string
+}
+
+// testTar returns a tar stream holding the entries in order.
+func testTar(t *testing.T, entries []tarEntry) *bytes.Buffer {
+	t.Helper()
+
+	buf := new(bytes.Buffer)
+	tw := tar.NewWriter(buf)
+	for _, entry := range entries {
+		header := &tar.Header{
+			Name:     entry.name,
+			Typeflag: entry.typeflag,
+			Linkname: entry.linkname,
+			Mode:     0644,
+			Size:     int64(len(entry.body)),
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(entry.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf
+}
+
+func TestExtractTarRejectsEscapes(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []tarEntry
+	}{
+		{"parent path", []tarEntry{
+			{name: "../evil.md", typeflag: tar.TypeReg, body: "outside"},
+		}},
+		{"absolute symlink", []tarEntry{
+			{name: "etc", typeflag: tar.TypeSymlink, linkname: "/etc"},
+		}},
+		{"relative symlink out of the root", []tarEntry{
+			{name: "lessons/up", typeflag: tar.TypeSymlink, linkname: "../../outside"},
+		}},
+		{"file written through a dangling symlink", []tarEntry{
+			{name: "lessons", typeflag: tar.TypeSymlink, linkname: "missing"},
+			{name: "lessons/evil.md", typeflag: tar.TypeReg, body: "outside"},
+		}},
+		{"file replacing a symlink", []tarEntry{
+			{name: "lessons", typeflag: tar.TypeDir},
+			{name: "index.md", typeflag: tar.TypeSymlink, linkname: "lessons"},
+			{name: "index.md", typeflag: tar.TypeReg, body: "through the link"},
+		}},
+		{"symlink through a symlink", []tarEntry{
+			{name: "lessons", typeflag: tar.TypeDir},
+			{name: "a", typeflag: tar.TypeSymlink, linkname: "lessons"},
+			{name: "a/b", typeflag: tar.TypeSymlink, linkname: "../../outside"},
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			parent := t.TempDir()
+			dir := filepath.Join(parent, "checkout")
+			if err := os.Mkdir(dir, 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := extractTar(testTar(t, test.entries), dir); err == nil {
+				t.Errorf("extractTar succeeded, want an error")
+			}
+
+			// Nothing was written next to the checkout
+			entries, err := os.ReadDir(parent)
+			if err != nil || len(entries) != 1 {
+				t.Errorf("entries next to the checkout = %v, %v, want only the checkout", entries, err)
+			}
+		})
+	}
+}
+
+func TestExtractTarKeepsLinksWithinRoot(t *testing.T) {
+	dir := t.TempDir()
+
+	err := extractTar(testTar(t, []tarEntry{
+		{name: "lessons/", typeflag: tar.TypeDir},
+		{name: "lessons/intro.md", typeflag: tar.TypeReg, body: "# Intro"},
+		{name: "latest.md", typeflag: tar.TypeSymlink, linkname: "lessons/intro.md"},
+		{name: "lessons/self", typeflag: tar.TypeSymlink, linkname: "."},
+	}), dir)
+	if err != nil {
+		t.Fatalf("extractTar failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "latest.md"))
+	if err != nil || string(data) != "# Intro" {
+		t.Errorf("latest.md = %q, %v, want %q", data, err, "# Intro")
+	}
+	if info, err := os.Lstat(filepath.Join(dir, "lessons", "self")); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("lessons/self is not kept as a symbolic link: %v", err)
+	}
+}
+
+func TestCheckoutCommitRefusesEscapingSymlinks(t *testing.T) {
+	repo := testGitRepo(t, map[string]string{"index.md": "# Welcome"})
+	if err := os.Symlink("../../outside", filepath.Join(repo, "escape")); err != nil {
+		t.Skipf("symbolic links unavailable: %v", err)
+	}
+	for _, args := range [][]string{
+		{"add", "-A"},
+		{"-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "-qm", "Add link"},
+	} {
+		if _, err := runGit(repo, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	commit, err := ResolveCommit(repo, "HEAD")
+	if err != nil {
+		t.Fatalf("ResolveCommit failed: %v", err)
+	}
+	if dir, err := checkoutCommit(repo, commit); err == nil {
+		os.RemoveAll(dir)
+		t.Errorf("checkoutCommit succeeded, want an unsafe symlink error")
+	}
+
+	if _, err := ResolveCommit(repo, "--output=/tmp/x"); err == nil {
+		t.Errorf("ResolveCommit accepted an option as a ref")
+	}
+}
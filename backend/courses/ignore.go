@@ -0,0 +1,315 @@
+/*
+ * File: ignore.go
+ * File Created: Monday, 19th October 2026 8:24:08 am
+ * Last Modified: Monday, 19th October 2026 5:54:25 pm
+ * Author: Akhil Datla
+ * Copyright © Akhil Datla 2023
+ */
+
+package courses
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// IgnoreFileName is the name of the file holding ignore patterns in the root of a course folder.
+const IgnoreFileName = ".learnadoignore"
+
+// DefaultIgnorePatterns are applied to every course folder before its own ignore file.
+var DefaultIgnorePatterns = []string{
+	".git/",
+	".svn/",
+	".hg/",
+	".idea/",
+	".vscode/",
+	".DS_Store",
+	"Thumbs.db",
+	"desktop.ini",
+	"*.swp",
+	"*.swo",
+	"*~",
+	".#*",
+	".env",
+	".env.*",
+	"*.pem",
+	"*.key",
+	"/" + IgnoreFileName,
+}
+
+// Symlink policies for course traversal.
+const (
+	SymlinkSkip             = "skip"
+	SymlinkFollowWithinRoot = "follow-within-root"
+	SymlinkError            = "error"
+)
+
+// SymlinkPolicy decides how symbolic links inside course folders are handled.
+var SymlinkPolicy = SymlinkSkip
+
+// ignoreRule represents a single compiled ignore pattern.
+type ignoreRule struct {
+	pattern *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// IgnoreMatcher matches relative paths against gitignore-style patterns.
+type IgnoreMatcher struct {
+	rules []ignoreRule
+}
+
+// NewIgnoreMatcher compiles the given gitignore-style patterns. Blank lines and lines
+// starting with # are skipped.
+func NewIgnoreMatcher(patterns []string) *IgnoreMatcher {
+	m := &IgnoreMatcher{}
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" || strings.HasPrefix(pattern, "#") {
+			continue
+		}
+
+		rule := ignoreRule{}
+		if strings.HasPrefix(pattern, "!") {
+			rule.negate = true
+			pattern = pattern[1:]
+		}
+		if strings.HasSuffix(pattern, "/") {
+			rule.dirOnly = true
+			pattern = strings.TrimSuffix(pattern, "/")
+		}
+
+		// Patterns containing a slash are anchored to the root, others match at any depth
+		anchored := strings.Contains(pattern, "/")
+		pattern = strings.TrimPrefix(pattern, "/")
+
+		expr := globToRegexp(pattern)
+		if !anchored {
+			expr = "(.*/)?" + expr
+		}
+		rule.pattern = regexp.MustCompile("^" + expr + "$")
+
+		m.rules = append(m.rules, rule)
+	}
+	return m
+}
+
+// LoadIgnoreMatcher builds a matcher from the default patterns and the ignore file in root, if any.
+func LoadIgnoreMatcher(root string) (*IgnoreMatcher, error) {
+	patterns := append([]string{}, DefaultIgnorePatterns...)
+
+	file, err := os.Open(filepath.Join(root, IgnoreFileName))
+	if os.IsNotExist(err) {
+		return NewIgnoreMatcher(patterns), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		patterns = append(patterns, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return NewIgnoreMatcher(patterns), nil
+}
+
+// Match reports whether the slash-separated relative path is ignored. The last matching
+// pattern wins, so negated patterns can re-include paths.
+func (m *IgnoreMatcher) Match(relativePath string, isDir bool) bool {
+	ignored := false
+	for _, rule := range m.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if rule.pattern.MatchString(relativePath) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// globToRegexp converts a gitignore glob into a regular expression.
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "/**") && i+3 == len(glob):
+			b.WriteString("(/.*)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i:], ']')
+			if end < 0 {
+				b.WriteString(regexp.QuoteMeta("["))
+				continue
+			}
+			class := glob[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
+
+// walkCourse walks the course folder at root, calling fn for every file and directory that is
+// not ignored, with its relative path and its resolved path on disk. Ignored paths and skipped
+// symbolic links are returned as excluded.
+func walkCourse(root string, fn func(relativePath, realPath string, isDir bool) error) ([]string, error) {
+	matcher, err := LoadIgnoreMatcher(root)
+	if err != nil {
+		return nil, err
+	}
+
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return nil, err
+	}
+
+	excluded := make([]string, 0)
+	visited := map[string]bool{realRoot: true}
+
+	var walk func(dir, relativeDir string) error
+	walk = func(dir, relativeDir string) error {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			relativePath := filepath.ToSlash(filepath.Join(relativeDir, entry.Name()))
+			path := filepath.Join(dir, entry.Name())
+
+			// Resolve symbolic links according to the policy
+			if entry.Type()&os.ModeSymlink != 0 {
+				switch SymlinkPolicy {
+				case SymlinkError:
+					return fmt.Errorf("symbolic link not allowed: %s", relativePath)
+				case SymlinkFollowWithinRoot:
+					target, err := filepath.EvalSymlinks(path)
+					if err != nil || !withinRoot(realRoot, target) {
+						excluded = append(excluded, relativePath)
+						continue
+					}
+					path = target
+				default:
+					excluded = append(excluded, relativePath)
+					continue
+				}
+			}
+
+			info, err := os.Stat(path)
+			if err != nil {
+				return err
+			}
+
+			if matcher.Match(relativePath, info.IsDir()) {
+				excluded = append(excluded, relativePath)
+				continue
+			}
+
+			if info.IsDir() {
+				// Guard against symbolic link loops
+				realPath, err := filepath.EvalSymlinks(path)
+				if err != nil {
+					return err
+				}
+				if visited[realPath] {
+					excluded = append(excluded, relativePath)
+					continue
+				}
+				visited[realPath] = true
+
+				if err := fn(relativePath, path, true); err != nil {
+					return err
+				}
+				if err := walk(path, relativePath); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := fn(relativePath, path, false); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	err = walk(root, "")
+	return excluded, err
+}
+
+// withinRoot reports whether the resolved path lies inside the resolved root.
+func withinRoot(root, path string) bool {
+	relativePath, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return relativePath != ".." && !strings.HasPrefix(relativePath, ".."+string(filepath.Separator))
+}
+
+// CopyCourse copies the course folder at src to dst, applying the ignore rules and the
+// symlink policy. It returns the relative paths that were excluded.
+func CopyCourse(src, dst string) ([]string, error) {
+	err := os.MkdirAll(dst, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	return walkCourse(src, func(relativePath, realPath string, isDir bool) error {
+		target := filepath.Join(dst, filepath.FromSlash(relativePath))
+		if isDir {
+			return os.MkdirAll(target, 0755)
+		}
+
+		data, err := os.ReadFile(realPath)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, 0666)
+	})
+}
+
+// ScanCourse returns the relative paths of the course source that would be excluded from a build,
+// reading git-backed courses from the commit a build would use.
+func ScanCourse(id string) ([]string, error) {
+	course, err := GetCourse(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid course id")
+	}
+
+	dir, _, cleanup, err := sourceDir(course)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	return walkCourse(dir, func(string, string, bool) error {
+		return nil
+	})
+}
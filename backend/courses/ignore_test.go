@@ -0,0 +1,174 @@
+/*
+ * File: ignore_test.go
+ * File Created: Monday, 19th October 2026 5:54:25 pm
+ * Last Modified: Monday, 19th October 2026 5:54:25 pm
+ * Author: Akhil Datla
+ * Copyright © Akhil Datla 2023
+ */
+
+package courses
+
+import (
+	"main/backend/dbmanager"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// openTestDatabase opens an empty database for the test.
+func openTestDatabase(t *testing.T) {
+	t.Helper()
+
+	if err := dbmanager.Open(filepath.Join(t.TempDir(), "test.db")); err != nil {
+		t.Fatalf("opening database: %v", err)
+	}
+	t.Cleanup(func() {
+		dbmanager.Close()
+	})
+}
+
+// writeFiles writes the files, keyed by slash-separated relative path, below root.
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+
+	for name, contents := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(contents), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// testGitRepo creates a git repository holding the files in a single commit and returns its path.
+func testGitRepo(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	repo := t.TempDir()
+	writeFiles(t, repo, files)
+
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "-A"},
+		{"-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "-qm", "Add course"},
+	} {
+		if _, err := runGit(repo, args...); err != nil {
+			t.Skipf("git unavailable: %v", err)
+		}
+	}
+	return repo
+}
+
+func TestIgnoreMatcher(t *testing.T) {
+	matcher := NewIgnoreMatcher([]string{
+		"# drafts are kept out of builds",
+		"",
+		"*.log",
+		"!keep.log",
+		"build/",
+		"/notes.md",
+		"docs/**/private",
+		"tmp",
+		"!tmp/",
+	})
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"debug.log", false, true},
+		{"lessons/debug.log", false, true},
+		{"keep.log", false, false},
+		{"lessons/keep.log", false, false},
+		{"build", true, true},
+		{"lessons/build", true, true},
+		{"build", false, false},
+		{"notes.md", false, true},
+		{"lessons/notes.md", false, false},
+		{"docs/private", false, true},
+		{"docs/a/b/private", true, true},
+		{"lessons/docs/private", false, false},
+		{"tmp", false, true},
+		{"tmp", true, false},
+		{"# drafts are kept out of builds", false, false},
+	}
+
+	for _, test := range tests {
+		if got := matcher.Match(test.path, test.isDir); got != test.want {
+			t.Errorf("Match(%q, %v) = %v, want %v", test.path, test.isDir, got, test.want)
+		}
+	}
+}
+
+func TestWalkCourseExcludes(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		IgnoreFileName:           "drafts/\n*.psd\n!cover.psd\n",
+		"index.md":               "# Welcome",
+		"cover.psd":              "cover",
+		"art/poster.psd":         "poster",
+		"drafts/lesson.md":       "draft",
+		"lessons/drafts":         "a file, not a folder",
+		".git/HEAD":              "ref: refs/heads/main",
+		"lessons/.DS_Store":      "",
+		"lessons/01-intro/a.md":  "# Intro",
+		"lessons/01-intro/b.swp": "",
+	})
+
+	visited := make([]string, 0)
+	excluded, err := walkCourse(root, func(relativePath, realPath string, isDir bool) error {
+		if !isDir {
+			visited = append(visited, relativePath)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walkCourse failed: %v", err)
+	}
+
+	sort.Strings(visited)
+	sort.Strings(excluded)
+	wantVisited := []string{"cover.psd", "index.md", "lessons/01-intro/a.md", "lessons/drafts"}
+	wantExcluded := []string{".git", IgnoreFileName, "art/poster.psd", "drafts", "lessons/.DS_Store", "lessons/01-intro/b.swp"}
+	sort.Strings(wantExcluded)
+
+	if strings.Join(visited, ",") != strings.Join(wantVisited, ",") {
+		t.Errorf("visited = %v, want %v", visited, wantVisited)
+	}
+	if strings.Join(excluded, ",") != strings.Join(wantExcluded, ",") {
+		t.Errorf("excluded = %v, want %v", excluded, wantExcluded)
+	}
+}
+
+func TestScanCourseReadsPinnedCommit(t *testing.T) {
+	openTestDatabase(t)
+
+	repo := testGitRepo(t, map[string]string{
+		"index.md":   "# Welcome",
+		"server.key": "committed secret",
+	})
+
+	// Files in the working directory that the commit does not hold are not built
+	writeFiles(t, repo, map[string]string{"draft.swp": "", "notes.pem": ""})
+
+	course := Course{ID: "scan-git", Name: "Scan", Filepath: repo}
+	if err := dbmanager.Save(&course); err != nil {
+		t.Fatalf("saving course: %v", err)
+	}
+	if err := SetGitSource(course.ID, "HEAD"); err != nil {
+		t.Fatalf("SetGitSource failed: %v", err)
+	}
+
+	excluded, err := ScanCourse(course.ID)
+	if err != nil {
+		t.Fatalf("ScanCourse failed: %v", err)
+	}
+	if strings.Join(excluded, ",") != "server.key" {
+		t.Errorf("ScanCourse = %v, want [server.key]", excluded)
+	}
+}
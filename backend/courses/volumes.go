@@ -0,0 +1,175 @@
+/*
+ * File: volumes.go
+ * File Created: Monday, 19th October 2026 7:30:39 am
+ * Last Modified: Monday, 19th October 2026 3:31:04 pm
+ * Author: Akhil Datla
+ * Copyright © Akhil Datla 2023
+ */
+
+package courses
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"main/backend/security"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Volume represents a single numbered piece of a split package.
+type Volume struct {
+	Index int    `json:"index"`
+	Name  string `json:"name"`
+	Size  int64  `json:"size"`
+	Hash  string `json:"hash"`
+}
+
+// VolumeManifest lists the volumes of a split package and their hashes.
+type VolumeManifest struct {
+	Package    string   `json:"package"`
+	Size       int64    `json:"size"`
+	Hash       string   `json:"hash"`
+	VolumeSize int64    `json:"volumeSize"`
+	Volumes    []Volume `json:"volumes"`
+}
+
+// SplitPackage splits the package file into numbered volumes of at most volumeSize bytes,
+// writing the volumes and a manifest into outputDir. It returns the manifest.
+func SplitPackage(packagePath, outputDir string, volumeSize int64) (VolumeManifest, error) {
+	if volumeSize <= 0 {
+		return VolumeManifest{}, errors.New("invalid volume size")
+	}
+
+	data, err := os.ReadFile(packagePath)
+	if err != nil {
+		return VolumeManifest{}, err
+	}
+
+	err = os.MkdirAll(outputDir, 0755)
+	if err != nil {
+		return VolumeManifest{}, err
+	}
+
+	packageName := filepath.Base(packagePath)
+	manifest := VolumeManifest{
+		Package:    packageName,
+		Size:       int64(len(data)),
+		Hash:       security.Hash(data),
+		VolumeSize: volumeSize,
+		Volumes:    make([]Volume, 0),
+	}
+
+	// Write each chunk of the package as its own volume
+	for offset, index := int64(0), 1; offset < int64(len(data)) || index == 1; index++ {
+		end := offset + volumeSize
+		if end > int64(len(data)) {
+			end = int64(len(data))
+		}
+		chunk := data[offset:end]
+
+		volume := Volume{
+			Index: index,
+			Name:  fmt.Sprintf("%s.%03d", packageName, index),
+			Size:  int64(len(chunk)),
+			Hash:  security.Hash(chunk),
+		}
+
+		err = os.WriteFile(filepath.Join(outputDir, volume.Name), chunk, 0666)
+		if err != nil {
+			return VolumeManifest{}, err
+		}
+
+		manifest.Volumes = append(manifest.Volumes, volume)
+		offset = end
+	}
+
+	// Write the manifest next to the volumes
+	manifestBytes, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return VolumeManifest{}, err
+	}
+
+	err = os.WriteFile(filepath.Join(outputDir, ManifestName(packageName)), manifestBytes, 0666)
+	if err != nil {
+		return VolumeManifest{}, err
+	}
+
+	return manifest, nil
+}
+
+// ManifestName returns the file name of the manifest for the given package name.
+func ManifestName(packageName string) string {
+	return strings.TrimSuffix(packageName, filepath.Ext(packageName)) + ".manifest.json"
+}
+
+// ReadManifest reads a volume manifest from the given path.
+func ReadManifest(manifestPath string) (VolumeManifest, error) {
+	var manifest VolumeManifest
+
+	manifestBytes, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return manifest, err
+	}
+
+	err = json.Unmarshal(manifestBytes, &manifest)
+	return manifest, err
+}
+
+// VerifyVolume checks that the volume in dir matches the size and hash recorded in the manifest.
+func VerifyVolume(dir string, volume Volume) error {
+	_, err := readVolume(dir, volume)
+	return err
+}
+
+// readVolume reads the volume from dir and checks it against the size and hash recorded in the
+// manifest. Volume names must be plain file names so that a manifest cannot reach outside dir.
+func readVolume(dir string, volume Volume) ([]byte, error) {
+	if volume.Name == "" || volume.Name == "." || volume.Name == ".." || strings.ContainsAny(volume.Name, `/\`) {
+		return nil, fmt.Errorf("invalid name of volume %d", volume.Index)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, volume.Name))
+	if err != nil {
+		return nil, fmt.Errorf("missing volume %d: %w", volume.Index, err)
+	}
+
+	if int64(len(data)) != volume.Size || security.Hash(data) != volume.Hash {
+		return nil, fmt.Errorf("corrupt volume %d", volume.Index)
+	}
+
+	return data, nil
+}
+
+// ReassembleVolumes verifies every volume listed in the manifest and joins them back into
+// the original package at outputPath.
+func ReassembleVolumes(manifestPath, outputPath string) error {
+	manifest, err := ReadManifest(manifestPath)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(manifestPath)
+	data := make([]byte, 0)
+
+	// Verify and append each volume in order
+	for i, volume := range manifest.Volumes {
+		if volume.Index != i+1 {
+			return fmt.Errorf("volume %d out of order", volume.Index)
+		}
+
+		chunk, err := readVolume(dir, volume)
+		if err != nil {
+			return err
+		}
+		data = append(data, chunk...)
+	}
+
+	// Verify the reassembled package as a whole
+	if int64(len(data)) != manifest.Size || security.Hash(data) != manifest.Hash {
+		return errors.New("reassembled package does not match manifest")
+	}
+
+	return os.WriteFile(outputPath, data, 0666)
+}
@@ -0,0 +1,144 @@
+/*
+ * File: volumes_test.go
+ * File Created: Monday, 19th October 2026 6:16:11 pm
+ * Last Modified: Monday, 19th October 2026 6:16:11 pm
+ * Author: Akhil Datla
+ * Copyright © Akhil Datla 2023
+ */
+
+package courses
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testPackage writes a package file of the given size into a new directory and returns its path.
+func testPackage(t *testing.T, size int) (string, []byte) {
+	t.Helper()
+
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+
+	path := filepath.Join(t.TempDir(), "HW-1.gob")
+	if err := os.WriteFile(path, data, 0666); err != nil {
+		t.Fatal(err)
+	}
+	return path, data
+}
+
+func TestSplitAndReassembleVolumes(t *testing.T) {
+	tests := []struct {
+		name       string
+		size       int
+		volumeSize int64
+		volumes    int
+	}{
+		{"empty package", 0, 10, 1},
+		{"smaller than a volume", 5, 10, 1},
+		{"exact volumes", 30, 10, 3},
+		{"partial last volume", 31, 10, 4},
+		{"one byte volumes", 4, 1, 4},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			packagePath, data := testPackage(t, test.size)
+			outputDir := t.TempDir()
+
+			manifest, err := SplitPackage(packagePath, outputDir, test.volumeSize)
+			if err != nil {
+				t.Fatalf("SplitPackage failed: %v", err)
+			}
+			if len(manifest.Volumes) != test.volumes {
+				t.Errorf("SplitPackage wrote %d volumes, want %d", len(manifest.Volumes), test.volumes)
+			}
+			if manifest.Volumes[0].Name != "HW-1.gob.001" {
+				t.Errorf("first volume is named %q, want %q", manifest.Volumes[0].Name, "HW-1.gob.001")
+			}
+
+			outputPath := filepath.Join(t.TempDir(), "HW-1.gob")
+			err = ReassembleVolumes(filepath.Join(outputDir, ManifestName("HW-1.gob")), outputPath)
+			if err != nil {
+				t.Fatalf("ReassembleVolumes failed: %v", err)
+			}
+
+			got, err := os.ReadFile(outputPath)
+			if err != nil || !bytes.Equal(got, data) {
+				t.Errorf("reassembled package differs from the original")
+			}
+		})
+	}
+
+	if _, err := SplitPackage("unused", t.TempDir(), 0); err == nil {
+		t.Errorf("SplitPackage with a volume size of 0 succeeded, want an error")
+	}
+}
+
+func TestReassembleVolumesRejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(dir string, manifest *VolumeManifest)
+	}{
+		{"missing volume", func(dir string, manifest *VolumeManifest) {
+			os.Remove(filepath.Join(dir, manifest.Volumes[1].Name))
+		}},
+		{"corrupt volume", func(dir string, manifest *VolumeManifest) {
+			os.WriteFile(filepath.Join(dir, manifest.Volumes[1].Name), bytes.Repeat([]byte{1}, 10), 0666)
+		}},
+		{"volumes out of order", func(dir string, manifest *VolumeManifest) {
+			manifest.Volumes[0], manifest.Volumes[1] = manifest.Volumes[1], manifest.Volumes[0]
+		}},
+		{"dropped volume", func(dir string, manifest *VolumeManifest) {
+			manifest.Volumes = manifest.Volumes[:2]
+			manifest.Volumes[1].Index = 2
+		}},
+		{"path in volume name", func(dir string, manifest *VolumeManifest) {
+			manifest.Volumes[0].Name = "../" + manifest.Volumes[0].Name
+		}},
+		{"backslash in volume name", func(dir string, manifest *VolumeManifest) {
+			manifest.Volumes[0].Name = `..\` + manifest.Volumes[0].Name
+		}},
+		{"parent directory as volume name", func(dir string, manifest *VolumeManifest) {
+			manifest.Volumes[0].Name = ".."
+		}},
+		{"empty volume name", func(dir string, manifest *VolumeManifest) {
+			manifest.Volumes[0].Name = ""
+		}},
+		{"changed package hash", func(dir string, manifest *VolumeManifest) {
+			manifest.Hash = manifest.Volumes[0].Hash
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			packagePath, _ := testPackage(t, 25)
+			outputDir := t.TempDir()
+
+			manifest, err := SplitPackage(packagePath, outputDir, 10)
+			if err != nil {
+				t.Fatalf("SplitPackage failed: %v", err)
+			}
+			test.change(outputDir, &manifest)
+
+			manifestPath := filepath.Join(outputDir, ManifestName("HW-1.gob"))
+			manifestBytes, _ := json.Marshal(manifest)
+			if err := os.WriteFile(manifestPath, manifestBytes, 0666); err != nil {
+				t.Fatal(err)
+			}
+
+			outputPath := filepath.Join(t.TempDir(), "HW-1.gob")
+			if err := ReassembleVolumes(manifestPath, outputPath); err == nil {
+				t.Errorf("ReassembleVolumes succeeded, want an error")
+			}
+			if _, err := os.Stat(outputPath); err == nil {
+				t.Errorf("ReassembleVolumes wrote a package despite the error")
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+/*
+ * File: watcher.go
+ * File Created: Monday, 19th October 2026 9:41:23 am
+ * Last Modified: Monday, 19th October 2026 5:43:00 pm
+ * Author: Akhil Datla
+ * Copyright © Akhil Datla 2023
+ */
+
+package courses
+
+import (
+	"fmt"
+	"main/backend/dbmanager"
+	"main/backend/security"
+	"os"
+	"sync"
+	"time"
+)
+
+// ChangeEvent describes a change to the files of a course.
+type ChangeEvent struct {
+	CourseID  string
+	Hash      string
+	UpdatedAt time.Time
+}
+
+var (
+	watchMutex   sync.Mutex
+	fingerprints = make(map[string]string)
+	listeners    = make([]func(ChangeEvent), 0)
+)
+
+// OnCourseChange registers a function that is called whenever the files of a course change.
+func OnCourseChange(fn func(ChangeEvent)) {
+	watchMutex.Lock()
+	listeners = append(listeners, fn)
+	watchMutex.Unlock()
+}
+
+// fingerprint returns a cheap summary of the course source that changes whenever its files might
+// have changed: the resolved commit for git-backed courses, or the paths, sizes and modification
+// times of the files otherwise.
+func fingerprint(course Course) (string, error) {
+	if course.GitRef != "" {
+		return ResolveCommit(course.Filepath, course.GitRef)
+	}
+
+	files := make(map[string]string)
+	_, err := walkCourse(course.Filepath, func(relativePath, realPath string, isDir bool) error {
+		if isDir {
+			files[relativePath] = ""
+			return nil
+		}
+
+		info, err := os.Stat(realPath)
+		if err != nil {
+			return err
+		}
+		files[relativePath] = fmt.Sprintf("%d:%d", info.Size(), info.ModTime().UnixNano())
+
+		return nil
+	})
+
+	return hashFiles(files), err
+}
+
+// contentHash returns a hash over the paths and contents of the course source.
+func contentHash(course Course) (string, error) {
+	if course.GitRef != "" {
+		commit, err := ResolveCommit(course.Filepath, course.GitRef)
+		return "git:" + commit, err
+	}
+
+	files := make(map[string]string)
+	_, err := walkCourse(course.Filepath, func(relativePath, realPath string, isDir bool) error {
+		if isDir {
+			files[relativePath] = ""
+			return nil
+		}
+
+		data, err := os.ReadFile(realPath)
+		if err != nil {
+			return err
+		}
+		files[relativePath] = security.Hash(data)
+
+		return nil
+	})
+
+	return hashFiles(files), err
+}
+
+// rememberFingerprint records the fingerprint of the course whose content hash is up to date.
+func rememberFingerprint(courseID, current string) {
+	watchMutex.Lock()
+	fingerprints[courseID] = current
+	watchMutex.Unlock()
+}
+
+// CheckCourse checks whether the files of the course changed since they were last recorded. On a
+// change it records the new content hash and update time, refreshes the search index and notifies
+// the registered listeners. It reports whether the course changed.
+func CheckCourse(course Course) (bool, error) {
+	current, err := fingerprint(course)
+	if err != nil {
+		return false, err
+	}
+
+	// Skip hashing the contents if nothing was touched
+	watchMutex.Lock()
+	unchanged := fingerprints[course.ID] == current
+	watchMutex.Unlock()
+	if unchanged {
+		return false, nil
+	}
+
+	hash, err := contentHash(course)
+	if err != nil {
+		return false, err
+	}
+
+	if hash == course.ContentHash {
+		rememberFingerprint(course.ID, current)
+		return false, nil
+	}
+
+	// Record the new content hash and update time
+	event := ChangeEvent{
+		CourseID:  course.ID,
+		Hash:      hash,
+		UpdatedAt: time.Now(),
+	}
+	err = dbmanager.Update(&Course{ID: course.ID, ContentHash: event.Hash, UpdatedAt: event.UpdatedAt})
+	if err != nil {
+		return false, err
+	}
+
+	// Only a recorded hash may skip later checks, so failed updates are retried
+	rememberFingerprint(course.ID, current)
+
+	// The first recorded hash is not a change
+	if course.ContentHash == "" {
+		return false, nil
+	}
+
+	course.ContentHash = event.Hash
+	course.UpdatedAt = event.UpdatedAt
+	err = indexCourse(course)
+
+	watchMutex.Lock()
+	notify := append([]func(ChangeEvent){}, listeners...)
+	watchMutex.Unlock()
+	for _, fn := range notify {
+		fn(event)
+	}
+
+	return true, err
+}
+
+// WatchCourses polls every registered course folder at the given interval until stop is closed,
+// returning the errors of each pass through the onError function.
+func WatchCourses(interval time.Duration, stop <-chan struct{}, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		allCourses, err := GetAllCourses()
+		if err != nil && onError != nil {
+			onError(err)
+		}
+
+		for _, course := range allCourses {
+			if _, err := CheckCourse(course); err != nil && onError != nil {
+				onError(fmt.Errorf("%s: %w", course.Name, err))
+			}
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
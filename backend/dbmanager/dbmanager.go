@@ -1,7 +1,7 @@
 /*
  * File: dbmanager.go
  * File Created: Sunday, 11th June 2023 9:57:15 pm
- * Last Modified: Friday, 23rd June 2023 12:43:18 am
+ * Last Modified: Monday, 19th October 2026 2:48:58 pm
  * Author: Akhil Datla
  * Copyright © Akhil Datla 2023
  */
@@ -9,18 +9,35 @@
 package dbmanager
 
 import (
+	"errors"
+
 	"github.com/asdine/storm"
+	"github.com/asdine/storm/q"
 )
 
+// organizationField is the name of the field holding the organization a record belongs to.
+const organizationField = "OrganizationID"
+
 var db *storm.DB
 
+// path is the file path of the open database.
+var path string
+
 // Open opens the database with the given name.
 func Open(name string) error {
 	var err error
 	db, err = storm.Open(name)
+	if err == nil {
+		path = name
+	}
 	return err
 }
 
+// Path returns the file path of the open database.
+func Path() string {
+	return path
+}
+
 // AutoCreateStruct creates a table for the given struct.
 func AutoCreateStruct(data interface{}) error {
 	err := db.Init(data)
@@ -51,6 +68,30 @@ func QueryAll(to interface{}) error {
 	return err
 }
 
+// OrganizationQuery retrieves a single record of the organization based on the specified field and value.
+func OrganizationQuery(organizationID, fieldName string, value, to interface{}) error {
+	err := db.Select(q.Eq(organizationField, organizationID), q.Eq(fieldName, value)).First(to)
+	return err
+}
+
+// OrganizationGroupQuery retrieves multiple records of the organization based on the specified field and value.
+func OrganizationGroupQuery(organizationID, fieldName string, value, to interface{}) error {
+	err := db.Select(q.Eq(organizationField, organizationID), q.Eq(fieldName, value)).Find(to)
+	return err
+}
+
+// OrganizationQueryAll retrieves all records of the specified struct belonging to the organization.
+// Unlike an index lookup, this also finds the records of the default organization, whose ID is empty.
+func OrganizationQueryAll(organizationID string, to interface{}) error {
+	err := db.Select(q.Eq(organizationField, organizationID)).Find(to)
+	return err
+}
+
+// IsNotFound reports whether the error of a query only means that no record matched.
+func IsNotFound(err error) bool {
+	return errors.Is(err, storm.ErrNotFound)
+}
+
 // Update updates the data (struct) in the database.
 func Update(data interface{}) error {
 	err := db.Update(data)
@@ -0,0 +1,246 @@
+/*
+ * File: export.go
+ * File Created: Monday, 19th October 2026 8:57:31 am
+ * Last Modified: Monday, 19th October 2026 3:42:12 pm
+ * Author: Akhil Datla
+ * Copyright © Akhil Datla 2023
+ */
+
+package interop
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"io/ioutil"
+	"main/backend/courses"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// runtimeScriptName is the file name of the SCORM runtime wrapper inside exported packages.
+const runtimeScriptName = "learnado-scorm.js"
+
+// runtimeScript finds the SCORM 1.2 API of the LMS, marks the page as completed when it is
+// opened, records quiz scores, and finishes the session when the page is left.
+const runtimeScript = `(function () {
+  function findAPI(win) {
+    for (var tries = 0; win && tries < 10; tries++) {
+      if (win.API) {
+        return win.API;
+      }
+      if (!win.parent || win.parent === win) {
+        break;
+      }
+      win = win.parent;
+    }
+    return null;
+  }
+
+  var api = findAPI(window) || (window.opener && findAPI(window.opener));
+  if (!api) {
+    return;
+  }
+
+  var finished = false;
+  api.LMSInitialize("");
+  var status = api.LMSGetValue("cmi.core.lesson_status");
+  if (status !== "passed" && status !== "failed") {
+    api.LMSSetValue("cmi.core.lesson_status", "completed");
+  }
+  api.LMSCommit("");
+
+  document.addEventListener("learnado:quizscore", function (event) {
+    api.LMSSetValue("cmi.core.score.raw", String(event.detail.score));
+    api.LMSSetValue("cmi.core.score.min", "0");
+    api.LMSSetValue("cmi.core.score.max", "100");
+    api.LMSSetValue("cmi.core.lesson_status", event.detail.passed ? "passed" : "failed");
+    api.LMSCommit("");
+  });
+
+  function finish() {
+    if (!finished) {
+      finished = true;
+      api.LMSFinish("");
+    }
+  }
+  window.addEventListener("pagehide", finish);
+  window.addEventListener("beforeunload", finish);
+})();
+`
+
+var (
+	titlePattern = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	headPattern  = regexp.MustCompile(`(?i)</head>`)
+)
+
+// ExportSCORM renders the course with the given ID through Hugo and writes it as a SCORM 1.2
+// zip with one SCO per page or section. It returns the path of the zip file.
+func ExportSCORM(courseID string) (string, error) {
+	course, err := courses.GetCourse(courseID)
+	if err != nil {
+		return "", errors.New("invalid course id")
+	}
+
+	// Render the course with relative links so it works from any LMS directory
+	m, report, err := courses.BuildPortableWebsite([]string{courseID})
+	if err != nil {
+		return "", err
+	}
+
+	// Hugo decides the folder of the course section
+	section, ok := report.Sections[courseID]
+	if !ok {
+		return "", errors.New("course has no rendered pages")
+	}
+	files := make(map[string][]byte)
+	scos := make([]string, 0)
+
+	for key, data := range m {
+		name := filepath.ToSlash(key)
+		if len(data) == 0 {
+			continue
+		}
+		files[name] = data
+
+		// Every rendered page of the course section becomes a SCO
+		isPage := strings.HasSuffix(name, ".html")
+		if isPage && (strings.HasPrefix(name, section+"/") || name == section+".html") {
+			scos = append(scos, name)
+		}
+	}
+
+	if len(scos) == 0 {
+		return "", errors.New("course has no rendered pages")
+	}
+	sort.Strings(scos)
+
+	// Load the runtime wrapper from every SCO
+	for _, name := range scos {
+		files[name] = injectRuntime(files[name], strings.Repeat("../", strings.Count(name, "/"))+runtimeScriptName)
+	}
+	files[runtimeScriptName] = []byte(runtimeScript)
+	files[manifestName] = scormManifest(course, scos, files)
+
+	// Write the zip file, removing it again if anything fails
+	zipFile, err := ioutil.TempFile("", "learnado-scorm-*.zip")
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		zipFile.Close()
+		if err != nil {
+			os.Remove(zipFile.Name())
+		}
+	}()
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	w := zip.NewWriter(zipFile)
+	for _, name := range names {
+		var entry io.Writer
+		entry, err = w.Create(name)
+		if err != nil {
+			return "", err
+		}
+		if _, err = entry.Write(files[name]); err != nil {
+			return "", err
+		}
+	}
+
+	if err = w.Close(); err != nil {
+		return "", err
+	}
+	return zipFile.Name(), nil
+}
+
+// injectRuntime adds a script tag loading the runtime wrapper to the page.
+func injectRuntime(page []byte, src string) []byte {
+	tag := []byte(`<script src="` + src + `"></script>`)
+
+	loc := headPattern.FindIndex(page)
+	if loc == nil {
+		return append(tag, page...)
+	}
+
+	result := append([]byte{}, page[:loc[0]]...)
+	result = append(result, tag...)
+	return append(result, page[loc[0]:]...)
+}
+
+// scormManifest generates the SCORM 1.2 imsmanifest.xml for the SCOs and shared files.
+func scormManifest(course courses.Course, scos []string, files map[string][]byte) []byte {
+	isSCO := make(map[string]bool)
+	for _, name := range scos {
+		isSCO[name] = true
+	}
+
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	fmt.Fprintf(&b, `<manifest identifier="learnado-%s" version="1.0"`, escape(course.ID))
+	b.WriteString(` xmlns="http://www.imsproject.org/xsd/imscp_rootv1p1p2"`)
+	b.WriteString(` xmlns:adlcp="http://www.adlnet.org/xsd/adlcp_rootv1p2"`)
+	b.WriteString(` xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"`)
+	b.WriteString(` xsi:schemaLocation="http://www.imsproject.org/xsd/imscp_rootv1p1p2 imscp_rootv1p1p2.xsd http://www.imsglobal.org/xsd/imsmd_rootv1p2p1 imsmd_rootv1p2p1.xsd http://www.adlnet.org/xsd/adlcp_rootv1p2 adlcp_rootv1p2.xsd">` + "\n")
+	b.WriteString("  <metadata>\n    <schema>ADL SCORM</schema>\n    <schemaversion>1.2</schemaversion>\n  </metadata>\n")
+
+	// Organization with one item per SCO
+	b.WriteString(`  <organizations default="ORG-1">` + "\n")
+	fmt.Fprintf(&b, "    <organization identifier=\"ORG-1\">\n      <title>%s</title>\n", escape(course.Name))
+	for i, name := range scos {
+		fmt.Fprintf(&b, "      <item identifier=\"ITEM-%d\" identifierref=\"SCO-%d\" isvisible=\"true\">\n", i+1, i+1)
+		fmt.Fprintf(&b, "        <title>%s</title>\n      </item>\n", escape(pageTitle(name, files[name])))
+	}
+	b.WriteString("    </organization>\n  </organizations>\n  <resources>\n")
+
+	// One resource per SCO, all depending on the shared files
+	for i, name := range scos {
+		fmt.Fprintf(&b, "    <resource identifier=\"SCO-%d\" type=\"webcontent\" adlcp:scormtype=\"sco\" href=\"%s\">\n", i+1, escape(name))
+		fmt.Fprintf(&b, "      <file href=\"%s\"/>\n      <dependency identifierref=\"SHARED\"/>\n    </resource>\n", escape(name))
+	}
+
+	names := make([]string, 0)
+	for name := range files {
+		if !isSCO[name] && name != manifestName {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	b.WriteString("    <resource identifier=\"SHARED\" type=\"webcontent\" adlcp:scormtype=\"asset\">\n")
+	for _, name := range names {
+		fmt.Fprintf(&b, "      <file href=\"%s\"/>\n", escape(name))
+	}
+	b.WriteString("    </resource>\n  </resources>\n</manifest>\n")
+
+	return b.Bytes()
+}
+
+// pageTitle extracts the title of a rendered page, falling back to its file name.
+func pageTitle(name string, page []byte) string {
+	if match := titlePattern.FindSubmatch(page); match != nil {
+		if title := strings.TrimSpace(html.UnescapeString(string(match[1]))); title != "" {
+			return title
+		}
+	}
+	return strings.TrimSuffix(path.Base(name), path.Ext(name))
+}
+
+// escape escapes text for use in XML content and attributes.
+func escape(s string) string {
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
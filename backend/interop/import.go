@@ -0,0 +1,461 @@
+/*
+ * File: import.go
+ * File Created: Monday, 19th October 2026 8:46:39 am
+ * Last Modified: Monday, 19th October 2026 11:41:41 am
+ * Author: Akhil Datla
+ * Copyright © Akhil Datla 2023
+ */
+
+package interop
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"main/backend/courses"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Package formats recognized by the importer.
+const (
+	FormatSCORM12     = "SCORM 1.2"
+	FormatSCORM2004   = "SCORM 2004"
+	FormatCommonCart  = "IMS Common Cartridge"
+	FormatUnknownIMS  = "IMS Content Package"
+	manifestName      = "imsmanifest.xml"
+	webLinkTypePrefix = "imswl_"
+)
+
+// ImportReport describes the outcome of an import.
+type ImportReport struct {
+	CourseID string   `json:"courseID"`
+	Format   string   `json:"format"`
+	Sections int      `json:"sections"`
+	Pages    int      `json:"pages"`
+	Skipped  []string `json:"skipped"`
+}
+
+// manifest mirrors the parts of imsmanifest.xml used by the importer.
+type manifest struct {
+	XMLName  xml.Name `xml:"manifest"`
+	Metadata struct {
+		Schema        string `xml:"schema"`
+		SchemaVersion string `xml:"schemaversion"`
+	} `xml:"metadata"`
+	Organizations struct {
+		Default       string         `xml:"default,attr"`
+		Organizations []organization `xml:"organization"`
+	} `xml:"organizations"`
+	Resources struct {
+		Resources []resource `xml:"resource"`
+	} `xml:"resources"`
+}
+
+// organization represents an organization tree in the manifest.
+type organization struct {
+	Identifier string `xml:"identifier,attr"`
+	Title      string `xml:"title"`
+	Items      []item `xml:"item"`
+}
+
+// item represents a node of an organization tree.
+type item struct {
+	Identifier    string `xml:"identifier,attr"`
+	IdentifierRef string `xml:"identifierref,attr"`
+	Title         string `xml:"title"`
+	Items         []item `xml:"item"`
+}
+
+// resource represents a resource in the manifest.
+type resource struct {
+	Identifier string `xml:"identifier,attr"`
+	Type       string `xml:"type,attr"`
+	Href       string `xml:"href,attr"`
+	Base       string `xml:"base,attr"`
+	Files      []struct {
+		Href string `xml:"href,attr"`
+	} `xml:"file"`
+	Dependencies []struct {
+		IdentifierRef string `xml:"identifierref,attr"`
+	} `xml:"dependency"`
+}
+
+// webLink mirrors a Common Cartridge web link resource.
+type webLink struct {
+	Title string `xml:"title"`
+	URL   struct {
+		Href string `xml:"href,attr"`
+	} `xml:"url"`
+}
+
+// importer holds the state of a single import.
+type importer struct {
+	files     map[string]*zip.File
+	resources map[string]resource
+	report    *ImportReport
+}
+
+var (
+	bodyPattern    = regexp.MustCompile(`(?is)<body[^>]*>(.*)</body>`)
+	nonSlugPattern = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// ImportPackage converts a SCORM 1.2/2004 or IMS Common Cartridge zip into a Hugo course folder
+// inside outputDir and registers it as a course of the server organization with the given ID.
+// If name is empty, the title of the package organization is used.
+func ImportPackage(organizationID, zipPath, name, outputDir string) (ImportReport, error) {
+	report := ImportReport{
+		Skipped: make([]string, 0),
+	}
+
+	reader, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return report, err
+	}
+	defer reader.Close()
+
+	imp := &importer{
+		files:     make(map[string]*zip.File),
+		resources: make(map[string]resource),
+		report:    &report,
+	}
+
+	// Index the archive by cleaned path, rejecting entries that escape the archive root
+	for _, f := range reader.File {
+		cleaned, ok := cleanPath(f.Name)
+		if !ok {
+			report.Skipped = append(report.Skipped, f.Name+": unsafe path")
+			continue
+		}
+		imp.files[cleaned] = f
+	}
+
+	// Parse the manifest
+	manifestFile, ok := imp.files[manifestName]
+	if !ok {
+		return report, errors.New("missing " + manifestName)
+	}
+	manifestBytes, err := readZipFile(manifestFile)
+	if err != nil {
+		return report, err
+	}
+
+	var m manifest
+	err = xml.Unmarshal(manifestBytes, &m)
+	if err != nil {
+		return report, fmt.Errorf("invalid manifest: %w", err)
+	}
+	report.Format = detectFormat(m, string(manifestBytes))
+
+	for _, r := range m.Resources.Resources {
+		imp.resources[r.Identifier] = r
+	}
+
+	// Pick the default organization, falling back to the first one
+	if len(m.Organizations.Organizations) == 0 {
+		return report, errors.New("manifest has no organization")
+	}
+	org := m.Organizations.Organizations[0]
+	for _, o := range m.Organizations.Organizations {
+		if o.Identifier == m.Organizations.Default {
+			org = o
+		}
+	}
+
+	if name == "" {
+		name = strings.TrimSpace(org.Title)
+	}
+	if name == "" {
+		return report, errors.New("missing course name")
+	}
+
+	// Common Cartridges wrap the tree in a single untitled root item
+	items := org.Items
+	if len(items) == 1 && items[0].IdentifierRef == "" && strings.TrimSpace(items[0].Title) == "" {
+		items = items[0].Items
+	}
+
+	// Write the course folder
+	courseDir := filepath.Join(outputDir, slugify(name))
+	if _, err := os.Stat(courseDir); err == nil {
+		return report, errors.New("course folder already exists")
+	}
+
+	err = os.MkdirAll(courseDir, 0755)
+	if err != nil {
+		return report, err
+	}
+
+	err = imp.writeItems(courseDir, items)
+	if err == nil {
+		// Register the course through the regular course creation path
+		report.CourseID, err = courses.CreateOrganizationCourse(organizationID, name, courseDir)
+	}
+	if err != nil {
+		os.RemoveAll(courseDir)
+		return report, err
+	}
+
+	return report, nil
+}
+
+// writeItems writes the items as sections and pages inside dir.
+func (imp *importer) writeItems(dir string, items []item) error {
+	used := make(map[string]bool)
+
+	for i, it := range items {
+		title := strings.TrimSpace(it.Title)
+		if title == "" {
+			title = it.Identifier
+		}
+
+		slug := slugify(title)
+		for n := 2; used[slug]; n++ {
+			slug = fmt.Sprintf("%s-%d", slugify(title), n)
+		}
+		used[slug] = true
+
+		// Every item becomes a bundle directory; items with children become sections
+		itemDir := filepath.Join(dir, slug)
+		err := os.MkdirAll(itemDir, 0755)
+		if err != nil {
+			return err
+		}
+
+		indexName := "index"
+		if len(it.Items) > 0 {
+			indexName = "_index"
+			imp.report.Sections++
+		} else {
+			imp.report.Pages++
+		}
+
+		err = imp.writePage(itemDir, indexName, title, i+1, it)
+		if err != nil {
+			return err
+		}
+
+		err = imp.writeItems(itemDir, it.Items)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writePage writes the content of the item's resource as the page indexName inside dir.
+func (imp *importer) writePage(dir, indexName, title string, weight int, it item) error {
+	frontMatter := pageFrontMatter(title, weight)
+
+	// Items without a resource only carry a title
+	if it.IdentifierRef == "" {
+		return os.WriteFile(filepath.Join(dir, indexName+".md"), []byte(frontMatter), 0666)
+	}
+
+	r, ok := imp.resources[it.IdentifierRef]
+	if !ok {
+		imp.report.Skipped = append(imp.report.Skipped, title+": missing resource "+it.IdentifierRef)
+		return os.WriteFile(filepath.Join(dir, indexName+".md"), []byte(frontMatter), 0666)
+	}
+
+	// Web links become Markdown pages pointing at the URL
+	if strings.HasPrefix(r.Type, webLinkTypePrefix) {
+		return imp.writeWebLink(dir, indexName, frontMatter, title, r)
+	}
+
+	href := resourcePath(r, r.Href)
+	if href == "" {
+		imp.report.Skipped = append(imp.report.Skipped, title+": unsupported resource type "+r.Type)
+		return os.WriteFile(filepath.Join(dir, indexName+".md"), []byte(frontMatter), 0666)
+	}
+
+	f, ok := imp.files[href]
+	if !ok {
+		imp.report.Skipped = append(imp.report.Skipped, title+": missing file "+href)
+		return os.WriteFile(filepath.Join(dir, indexName+".md"), []byte(frontMatter), 0666)
+	}
+
+	// Other files, such as documents and videos, become pages linking to the copied file
+	ext := strings.ToLower(path.Ext(href))
+	if ext != ".html" && ext != ".htm" {
+		content := frontMatter + fmt.Sprintf("[%s](%s)\n", title, path.Base(href))
+		err := os.WriteFile(filepath.Join(dir, indexName+".md"), []byte(content), 0666)
+		if err != nil {
+			return err
+		}
+		return imp.copyResourceFiles(dir, path.Dir(href), r, map[string]bool{})
+	}
+
+	html, err := readZipFile(f)
+	if err != nil {
+		return err
+	}
+
+	// Keep only the body of the launch page
+	body := string(html)
+	if match := bodyPattern.FindStringSubmatch(body); match != nil {
+		body = match[1]
+	}
+
+	err = os.WriteFile(filepath.Join(dir, indexName+".html"), []byte(frontMatter+body), 0666)
+	if err != nil {
+		return err
+	}
+
+	// Copy the files of the resource and its dependencies next to the page
+	return imp.copyResourceFiles(dir, path.Dir(href), r, map[string]bool{})
+}
+
+// writeWebLink writes a Common Cartridge web link as a Markdown page.
+func (imp *importer) writeWebLink(dir, indexName, frontMatter, title string, r resource) error {
+	content := frontMatter
+	if len(r.Files) > 0 {
+		if f, ok := imp.files[resourcePath(r, r.Files[0].Href)]; ok {
+			data, err := readZipFile(f)
+			if err != nil {
+				return err
+			}
+
+			var link webLink
+			if xml.Unmarshal(data, &link) == nil && link.URL.Href != "" {
+				content += fmt.Sprintf("[%s](%s)\n", title, link.URL.Href)
+			}
+		}
+	}
+
+	if content == frontMatter {
+		imp.report.Skipped = append(imp.report.Skipped, title+": unreadable web link")
+	}
+
+	return os.WriteFile(filepath.Join(dir, indexName+".md"), []byte(content), 0666)
+}
+
+// copyResourceFiles copies the files of a resource and its dependencies into dir, keeping their
+// paths relative to the launch page directory baseDir.
+func (imp *importer) copyResourceFiles(dir, baseDir string, r resource, seen map[string]bool) error {
+	if seen[r.Identifier] {
+		return nil
+	}
+	seen[r.Identifier] = true
+
+	for _, file := range r.Files {
+		name := resourcePath(r, file.Href)
+		ext := strings.ToLower(path.Ext(name))
+		if ext == ".html" || ext == ".htm" {
+			// Other HTML files would become Hugo pages of their own
+			if name != resourcePath(r, r.Href) {
+				imp.report.Skipped = append(imp.report.Skipped, name+": additional html file")
+			}
+			continue
+		}
+
+		relativePath := name
+		if baseDir != "." {
+			relativePath = strings.TrimPrefix(name, baseDir+"/")
+			if relativePath == name {
+				imp.report.Skipped = append(imp.report.Skipped, name+": outside the page directory")
+				continue
+			}
+		}
+
+		f, ok := imp.files[name]
+		if !ok {
+			imp.report.Skipped = append(imp.report.Skipped, name+": missing file")
+			continue
+		}
+
+		data, err := readZipFile(f)
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dir, filepath.FromSlash(relativePath))
+		err = os.MkdirAll(filepath.Dir(target), 0755)
+		if err != nil {
+			return err
+		}
+		err = os.WriteFile(target, data, 0666)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, dependency := range r.Dependencies {
+		if d, ok := imp.resources[dependency.IdentifierRef]; ok {
+			err := imp.copyResourceFiles(dir, baseDir, d, seen)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// detectFormat determines the package format from the manifest metadata and namespaces.
+func detectFormat(m manifest, raw string) string {
+	version := strings.ToLower(m.Metadata.SchemaVersion)
+	switch {
+	case strings.Contains(raw, "imsccv1p") || strings.Contains(strings.ToLower(m.Metadata.Schema), "common cartridge"):
+		return FormatCommonCart
+	case version == "1.2":
+		return FormatSCORM12
+	case strings.HasPrefix(version, "2004") || strings.Contains(version, "cam 1.3"):
+		return FormatSCORM2004
+	}
+	return FormatUnknownIMS
+}
+
+// resourcePath resolves a file reference of a resource against its xml:base.
+func resourcePath(r resource, href string) string {
+	if href == "" {
+		return ""
+	}
+	if i := strings.IndexAny(href, "?#"); i >= 0 {
+		href = href[:i]
+	}
+	cleaned, _ := cleanPath(path.Join(r.Base, href))
+	return cleaned
+}
+
+// cleanPath cleans a slash-separated archive path and reports whether it stays inside the archive.
+func cleanPath(name string) (string, bool) {
+	cleaned := path.Clean(strings.ReplaceAll(name, `\`, "/"))
+	if path.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", false
+	}
+	return cleaned, true
+}
+
+// readZipFile reads the whole content of a file in the archive.
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// pageFrontMatter returns YAML front matter with the title and weight of a page.
+func pageFrontMatter(title string, weight int) string {
+	quotedTitle, _ := json.Marshal(title)
+	return fmt.Sprintf("---\ntitle: %s\nweight: %d\n---\n", quotedTitle, weight)
+}
+
+// slugify turns a title into a lowercase folder name.
+func slugify(title string) string {
+	slug := strings.Trim(nonSlugPattern.ReplaceAllString(strings.ToLower(title), "-"), "-")
+	if slug == "" {
+		slug = "untitled"
+	}
+	return slug
+}
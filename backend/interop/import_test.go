@@ -0,0 +1,169 @@
+/*
+ * File: import_test.go
+ * File Created: Monday, 19th October 2026 6:27:10 pm
+ * Last Modified: Monday, 19th October 2026 6:27:10 pm
+ * Author: Akhil Datla
+ * Copyright © Akhil Datla 2023
+ */
+
+package interop
+
+import (
+	"archive/zip"
+	"main/backend/dbmanager"
+	"main/backend/organizations"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestCleanPath(t *testing.T) {
+	tests := []struct {
+		name   string
+		want   string
+		wantOK bool
+	}{
+		{"imsmanifest.xml", "imsmanifest.xml", true},
+		{"lessons/./intro/index.html", "lessons/intro/index.html", true},
+		{"lessons/../shared/style.css", "shared/style.css", true},
+		{`lessons\intro\index.html`, "lessons/intro/index.html", true},
+		{"lessons/", "lessons", true},
+		{"..", "", false},
+		{"../evil.html", "", false},
+		{"lessons/../../evil.html", "", false},
+		{`..\evil.html`, "", false},
+		{"/etc/passwd", "", false},
+		{`\windows\evil.html`, "", false},
+	}
+
+	for _, test := range tests {
+		got, ok := cleanPath(test.name)
+		if got != test.want || ok != test.wantOK {
+			t.Errorf("cleanPath(%q) = %q, %v, want %q, %v", test.name, got, ok, test.want, test.wantOK)
+		}
+	}
+}
+
+func TestResourcePath(t *testing.T) {
+	tests := []struct {
+		base string
+		href string
+		want string
+	}{
+		{"", "index.html", "index.html"},
+		{"lessons/", "intro.html", "lessons/intro.html"},
+		{"lessons", "intro.html?page=1#top", "lessons/intro.html"},
+		{"lessons/", "../shared/style.css", "shared/style.css"},
+		{"lessons/", "../../escape.js", ""},
+		{"", "/etc/passwd", ""},
+		{"lessons/", "", ""},
+	}
+
+	for _, test := range tests {
+		got := resourcePath(resource{Base: test.base}, test.href)
+		if got != test.want {
+			t.Errorf("resourcePath(%q, %q) = %q, want %q", test.base, test.href, got, test.want)
+		}
+	}
+}
+
+// testZip writes the files, keyed by their name in the archive, into a new zip and returns its path.
+func testZip(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	zipPath := filepath.Join(t.TempDir(), "package.zip")
+	file, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	w := zip.NewWriter(file)
+	for name, contents := range files {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Write([]byte(contents)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return zipPath
+}
+
+const testManifest = `<?xml version="1.0"?>
+<manifest identifier="course">
+  <metadata><schema>ADL SCORM</schema><schemaversion>1.2</schemaversion></metadata>
+  <organizations default="org">
+    <organization identifier="org">
+      <title>Path Cleaning</title>
+      <item identifier="i1" identifierref="r1"><title>Intro</title></item>
+    </organization>
+  </organizations>
+  <resources>
+    <resource identifier="r1" type="webcontent" href="intro.html" xml:base="lessons/">
+      <file href="intro.html"/>
+      <file href="images/../images/logo.png"/>
+      <file href="../../escape.js"/>
+      <file href="../shared/style.css"/>
+    </resource>
+  </resources>
+</manifest>`
+
+func TestImportPackageCleansPaths(t *testing.T) {
+	if err := dbmanager.Open(filepath.Join(t.TempDir(), "test.db")); err != nil {
+		t.Fatalf("opening database: %v", err)
+	}
+	t.Cleanup(func() {
+		dbmanager.Close()
+	})
+
+	zipPath := testZip(t, map[string]string{
+		"imsmanifest.xml":         testManifest,
+		"lessons/intro.html":      "<html><body><p>Hello</p></body></html>",
+		"lessons/images/logo.png": "logo",
+		"shared/style.css":        "body{}",
+		"../evil.html":            "outside",
+		`..\evil.txt`:             "outside",
+		"/absolute.txt":           "outside",
+	})
+
+	outputDir := filepath.Join(t.TempDir(), "courses")
+	report, err := ImportPackage(organizations.Default, zipPath, "", outputDir)
+	if err != nil {
+		t.Fatalf("ImportPackage failed: %v", err)
+	}
+	if report.Format != FormatSCORM12 || report.Pages != 1 {
+		t.Errorf("ImportPackage = %+v, want one page of a %s package", report, FormatSCORM12)
+	}
+
+	// Only the files of the launch page directory are written, and only inside the course folder
+	written := make([]string, 0)
+	err = filepath.Walk(filepath.Dir(outputDir), func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			relativePath, _ := filepath.Rel(outputDir, path)
+			written = append(written, filepath.ToSlash(relativePath))
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(written)
+	want := []string{"path-cleaning/intro/images/logo.png", "path-cleaning/intro/index.html"}
+	if strings.Join(written, ",") != strings.Join(want, ",") {
+		t.Errorf("written files = %v, want %v", written, want)
+	}
+
+	skipped := strings.Join(report.Skipped, "\n")
+	for _, name := range []string{"../evil.html", `..\evil.txt`, "/absolute.txt", "shared/style.css: outside the page directory"} {
+		if !strings.Contains(skipped, name) {
+			t.Errorf("skipped = %v, want it to list %q", report.Skipped, name)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+/*
+ * File: batches.go
+ * File Created: Monday, 19th October 2026 11:09:36 am
+ * Last Modified: Monday, 19th October 2026 4:15:02 pm
+ * Author: Akhil Datla
+ * Copyright © Akhil Datla 2023
+ */
+
+package licensing
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"main/backend/courses"
+	"main/backend/dbmanager"
+)
+
+// LicenseBatch represents a set of licenses generated together, such as the keys of one order.
+type LicenseBatch struct {
+	ID             string    `storm:"id" json:"id"`
+	Label          string    `json:"label"`
+	Customer       string    `storm:"index" json:"customer"`
+	CourseID       string    `storm:"index" json:"courseID"`
+	BundleID       string    `storm:"index" json:"bundleID"`
+	OrganizationID string    `storm:"index" json:"organizationID"`
+	CreatedBy      string    `json:"createdBy"`
+	CreatedAt      time.Time `json:"createdAt"`
+	Count          int       `json:"count"`
+	Revoked        int       `json:"revoked"`
+}
+
+// GenerateBatch generates num licenses with the given terms for the course or the bundle of the
+// batch, which must belong to the organization of the batch, and records the batch with its label,
+// customer and creator. It returns the batch and the license keys. If generating a key fails, the
+// batch records only the keys created before the failure.
+func GenerateBatch(batch LicenseBatch, num int, terms Terms) (LicenseBatch, []string, error) {
+	var err error
+	if batch.BundleID != "" {
+		batch.CourseID = ""
+		bundle, err := GetBundle(batch.BundleID)
+		if err != nil || bundle.OrganizationID != batch.OrganizationID {
+			return LicenseBatch{}, nil, errors.New("invalid bundle id")
+		}
+	} else {
+		var course courses.Course
+		err = dbmanager.Query("ID", batch.CourseID, &course)
+		if err != nil || course.OrganizationID != batch.OrganizationID {
+			return LicenseBatch{}, nil, errors.New("invalid course id")
+		}
+	}
+
+	if num < 1 {
+		return LicenseBatch{}, nil, errors.New("invalid number of licenses")
+	}
+
+	terms, err = checkTerms(batch.CourseID, terms)
+	if err != nil {
+		return LicenseBatch{}, nil, err
+	}
+
+	batch.ID = uuid.NewV4().String()
+	batch.CreatedAt = time.Now()
+	batch.Count = num
+	batch.Revoked = 0
+
+	err = dbmanager.Save(&batch)
+	if err != nil {
+		return LicenseBatch{}, nil, err
+	}
+
+	licenses := make([]string, 0, num)
+	for i := 0; i < num; i++ {
+		// Generate a license for each iteration
+		key, err := newLicense(batch, terms)
+		if err != nil {
+			// Record how many keys the batch really holds
+			batch.Count = len(licenses)
+			dbmanager.Save(&batch)
+			return batch, licenses, err
+		}
+		licenses = append(licenses, key)
+	}
+
+	return batch, licenses, nil
+}
+
+// GetAllBatches retrieves all license batches, newest first.
+func GetAllBatches() ([]LicenseBatch, error) {
+	var batches []LicenseBatch
+	err := dbmanager.QueryAll(&batches)
+
+	sort.Slice(batches, func(i, j int) bool {
+		return batches[i].CreatedAt.After(batches[j].CreatedAt)
+	})
+
+	return batches, err
+}
+
+// GetOrganizationBatches retrieves the license batches of the organization with the given ID, newest first.
+func GetOrganizationBatches(organizationID string) ([]LicenseBatch, error) {
+	var batches []LicenseBatch
+	err := dbmanager.OrganizationQueryAll(organizationID, &batches)
+	if dbmanager.IsNotFound(err) {
+		return make([]LicenseBatch, 0), nil
+	}
+
+	sort.Slice(batches, func(i, j int) bool {
+		return batches[i].CreatedAt.After(batches[j].CreatedAt)
+	})
+
+	return batches, err
+}
+
+// GetBatch retrieves the license batch with the given ID.
+func GetBatch(batchID string) (LicenseBatch, error) {
+	var batch LicenseBatch
+	err := dbmanager.Query("ID", batchID, &batch)
+	if err != nil {
+		return LicenseBatch{}, errors.New("invalid batch id")
+	}
+	return batch, nil
+}
+
+// GetBatchLicenses retrieves the licenses of the batch with the given ID including revoked ones.
+func GetBatchLicenses(batchID string) ([]License, error) {
+	var licenses []License
+	err := dbmanager.GroupQuery("BatchID", batchID, &licenses)
+	if dbmanager.IsNotFound(err) {
+		// Batches from before licenses were kept may have none left
+		return make([]License, 0), nil
+	}
+
+	sort.Slice(licenses, func(i, j int) bool {
+		return licenses[i].ID < licenses[j].ID
+	})
+
+	return licenses, err
+}
+
+// ExportBatchCSV writes the licenses of the batch with the given ID as CSV, one row per license
+// with its key, status, seats, the seats taken and the hardware IDs that took them.
+func ExportBatchCSV(batchID string) ([]byte, error) {
+	batch, err := GetBatch(batchID)
+	if err != nil {
+		return nil, err
+	}
+
+	licenses, err := GetBatchLicenses(batch.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := new(bytes.Buffer)
+	w := csv.NewWriter(buf)
+	w.Write([]string{"key", "status", "batch", "customer", "course", "bundle", "seats", "activations", "hardwareIDs", "expiresAt"})
+
+	for _, license := range licenses {
+		activations, err := GetLicenseActivations(license.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		taken := seatHolders(activations)
+
+		expiresAt := ""
+		if !license.ExpiresAt.IsZero() {
+			expiresAt = license.ExpiresAt.Format(time.RFC3339)
+		}
+
+		w.Write([]string{
+			FormatLicenseKey(license.ID, license.Prefix),
+			license.StatusAt(time.Now()),
+			batch.Label,
+			batch.Customer,
+			license.CourseID,
+			license.BundleID,
+			strconv.Itoa(license.seats()),
+			strconv.Itoa(len(taken)),
+			strings.Join(taken, ";"),
+			expiresAt,
+		})
+	}
+
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// RevokeUnusedLicenses revokes every license of the batch with the given ID that was never
+// activated and returns how many were revoked. Activated licenses are left to RevokeEntitlements.
+func RevokeUnusedLicenses(batchID string) (int, error) {
+	registerMutex.Lock()
+	defer registerMutex.Unlock()
+
+	batch, err := GetBatch(batchID)
+	if err != nil {
+		return 0, err
+	}
+
+	licenses, err := GetBatchLicenses(batch.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	revoked := 0
+	for _, license := range licenses {
+		if license.Status == StatusRevoked {
+			continue
+		}
+
+		activations, err := GetLicenseActivations(license.ID)
+		if err != nil {
+			return revoked, err
+		}
+		if len(activations) > 0 {
+			continue
+		}
+
+		err = revokeLicense(license, "unused license of batch "+batch.ID)
+		if err != nil {
+			return revoked, err
+		}
+		revoked++
+	}
+
+	err = dbmanager.Update(&LicenseBatch{ID: batch.ID, Revoked: batch.Revoked + revoked})
+	return revoked, err
+}
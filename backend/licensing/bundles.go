@@ -0,0 +1,277 @@
+/*
+ * File: bundles.go
+ * File Created: Monday, 19th October 2026 11:30:43 am
+ * Last Modified: Monday, 19th October 2026 5:10:12 pm
+ * Author: Akhil Datla
+ * Copyright © Akhil Datla 2023
+ */
+
+package licensing
+
+import (
+	"errors"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"main/backend/courses"
+	"main/backend/dbmanager"
+)
+
+// Bundle represents a named set of courses sold under one license, such as the courses of a grade.
+// The courses of a learning path are meant to be taken in the listed order.
+type Bundle struct {
+	ID             string   `storm:"id" json:"id"`
+	Name           string   `storm:"index" json:"name"`
+	OrganizationID string   `storm:"index" json:"organizationID"`
+	CourseIDs      []string `json:"courseIDs"`
+	LearningPath   bool     `json:"learningPath"`
+}
+
+// checkCourses checks that every course ID refers to a course of the organization.
+func checkCourses(organizationID string, courseIDs []string) error {
+	for _, courseID := range courseIDs {
+		var course courses.Course
+		if dbmanager.Query("ID", courseID, &course) != nil || course.OrganizationID != organizationID {
+			return errors.New("invalid course id " + courseID)
+		}
+	}
+	return nil
+}
+
+// CreateBundle creates a bundle of the given courses of the organization and returns its ID.
+func CreateBundle(organizationID, name string, courseIDs []string, learningPath bool) (string, error) {
+	if name == "" {
+		return "", errors.New("invalid bundle name")
+	}
+	if len(courseIDs) == 0 {
+		return "", errors.New("bundle needs at least one course")
+	}
+	if err := checkCourses(organizationID, courseIDs); err != nil {
+		return "", err
+	}
+
+	var existing Bundle
+	if dbmanager.OrganizationQuery(organizationID, "Name", name, &existing) == nil {
+		return "", errors.New("bundle name already in use")
+	}
+
+	bundle := &Bundle{
+		ID:             uuid.NewV4().String(),
+		Name:           name,
+		OrganizationID: organizationID,
+		CourseIDs:      make([]string, 0, len(courseIDs)),
+		LearningPath:   learningPath,
+	}
+	for _, courseID := range courseIDs {
+		if !contains(bundle.CourseIDs, courseID) {
+			bundle.CourseIDs = append(bundle.CourseIDs, courseID)
+		}
+	}
+
+	// Save the bundle to the database
+	err := dbmanager.Save(bundle)
+	return bundle.ID, err
+}
+
+// GetBundle retrieves the bundle with the given ID.
+func GetBundle(bundleID string) (Bundle, error) {
+	var bundle Bundle
+	err := dbmanager.Query("ID", bundleID, &bundle)
+	if err != nil {
+		return Bundle{}, errors.New("invalid bundle id")
+	}
+	return bundle, nil
+}
+
+// GetAllBundles retrieves all bundles.
+func GetAllBundles() ([]Bundle, error) {
+	var bundles []Bundle
+	err := dbmanager.QueryAll(&bundles)
+	return bundles, err
+}
+
+// GetOrganizationBundles retrieves the bundles of the organization with the given ID.
+func GetOrganizationBundles(organizationID string) ([]Bundle, error) {
+	var bundles []Bundle
+	err := dbmanager.OrganizationQueryAll(organizationID, &bundles)
+	if dbmanager.IsNotFound(err) {
+		return make([]Bundle, 0), nil
+	}
+	return bundles, err
+}
+
+// AddBundleCourses adds courses to the bundle with the given ID. Every device that activated a
+// license of the bundle is entitled to the new courses until its entitlements to the bundle expire.
+// It returns the number of entitlements created.
+func AddBundleCourses(bundleID string, courseIDs []string) (int, error) {
+	registerMutex.Lock()
+	defer registerMutex.Unlock()
+
+	bundle, err := GetBundle(bundleID)
+	if err != nil {
+		return 0, err
+	}
+	if err := checkCourses(bundle.OrganizationID, courseIDs); err != nil {
+		return 0, err
+	}
+
+	for _, courseID := range courseIDs {
+		if !contains(bundle.CourseIDs, courseID) {
+			bundle.CourseIDs = append(bundle.CourseIDs, courseID)
+		}
+	}
+	err = dbmanager.Save(&bundle)
+	if err != nil {
+		return 0, err
+	}
+
+	// Entitle the devices holding a seat of a license of the bundle
+	var licenses []License
+	err = dbmanager.GroupQuery("BundleID", bundle.ID, &licenses)
+	if err != nil && !dbmanager.IsNotFound(err) {
+		return 0, err
+	}
+
+	now := time.Now()
+	created := 0
+	for _, license := range licenses {
+		activations, err := GetLicenseActivations(license.ID)
+		if err != nil {
+			return created, err
+		}
+
+		for _, hardwareID := range seatHolders(activations) {
+			n, err := entitleBundle(license, bundle, hardwareID, activations, now)
+			if err != nil {
+				return created, err
+			}
+			created += n
+		}
+	}
+
+	return created, nil
+}
+
+// RemoveBundleCourse removes a course from the bundle with the given ID. Devices that activated a
+// license of the bundle keep their entitlement to the course; later activations do not get it.
+// The last course of a bundle cannot be removed.
+func RemoveBundleCourse(bundleID, courseID string) error {
+	registerMutex.Lock()
+	defer registerMutex.Unlock()
+
+	bundle, err := GetBundle(bundleID)
+	if err != nil {
+		return err
+	}
+
+	courseIDs := make([]string, 0, len(bundle.CourseIDs))
+	for _, id := range bundle.CourseIDs {
+		if id != courseID {
+			courseIDs = append(courseIDs, id)
+		}
+	}
+	if len(courseIDs) == len(bundle.CourseIDs) {
+		return errors.New("course not in bundle")
+	}
+	if len(courseIDs) == 0 {
+		return errors.New("cannot remove the last course of a bundle")
+	}
+
+	bundle.CourseIDs = courseIDs
+	return dbmanager.Save(&bundle)
+}
+
+// DeleteBundle deletes the bundle with the given ID unless licenses were generated for it.
+func DeleteBundle(bundleID string) error {
+	bundle, err := GetBundle(bundleID)
+	if err != nil {
+		return err
+	}
+
+	var licenses []License
+	err = dbmanager.GroupQuery("BundleID", bundle.ID, &licenses)
+	if err != nil && !dbmanager.IsNotFound(err) {
+		return err
+	}
+	if len(licenses) > 0 {
+		return errors.New("bundle has licenses")
+	}
+
+	return dbmanager.Delete(&bundle)
+}
+
+// entitleBundle creates the missing entitlements of the hardware ID to the courses of the bundle
+// through the license, expiring with the entitlements the hardware ID already holds through it.
+// Devices whose entitlements through the license were all revoked or expired get nothing.
+// It returns the number of entitlements created.
+func entitleBundle(license License, bundle Bundle, hardwareID string, activations []Entitlement, now time.Time) (int, error) {
+	held := make([]string, 0)
+	var expiresAt time.Time
+	active := false
+	for _, activation := range activations {
+		if activation.HardwareID != hardwareID {
+			continue
+		}
+		held = append(held, activation.CourseID)
+
+		if activation.IsRevoked() || activation.IsExpired(now) {
+			continue
+		}
+		if !active || (!expiresAt.IsZero() && (activation.ExpiresAt.IsZero() || activation.ExpiresAt.After(expiresAt))) {
+			expiresAt = activation.ExpiresAt
+		}
+		active = true
+	}
+	if !active {
+		return 0, nil
+	}
+
+	// Courses revoked for the device are not granted again
+	revoked, err := revokedCourses(license, hardwareID, activations)
+	if err != nil {
+		return 0, err
+	}
+
+	created := 0
+	for _, courseID := range bundle.CourseIDs {
+		if contains(held, courseID) || contains(revoked, courseID) {
+			continue
+		}
+
+		err := dbmanager.Save(&Entitlement{
+			ID:             uuid.NewV4().String(),
+			CourseID:       courseID,
+			HardwareID:     hardwareID,
+			LicenseID:      license.ID,
+			OrganizationID: license.OrganizationID,
+			ActivatedAt:    now,
+			ExpiresAt:      expiresAt,
+		})
+		if err != nil {
+			return created, err
+		}
+		created++
+		recordEvent(license.ID, EventBundleExtended, hardwareID, "course "+courseID)
+	}
+
+	// The device needs to sync to receive the new courses
+	if created > 0 {
+		markDeviceStale(hardwareID)
+	}
+
+	return created, nil
+}
+
+// courseIDs returns the IDs of the courses the license grants: the courses of its bundle, or its course.
+func (l License) courseIDs() ([]string, error) {
+	if l.BundleID == "" {
+		return []string{l.CourseID}, nil
+	}
+
+	bundle, err := GetBundle(l.BundleID)
+	if err != nil {
+		return nil, err
+	}
+	return bundle.CourseIDs, nil
+}
@@ -0,0 +1,140 @@
+/*
+ * File: codes.go
+ * File Created: Monday, 19th October 2026 10:47:08 am
+ * Last Modified: Monday, 19th October 2026 10:47:08 am
+ * Author: Akhil Datla
+ * Copyright © Akhil Datla 2023
+ */
+
+package licensing
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+)
+
+// crockfordAlphabet is the Crockford base32 alphabet, which leaves out I, L, O and U so that codes
+// can be read out and typed without confusion.
+const crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// crockfordChecks extends the alphabet with the five extra check symbols.
+const crockfordChecks = crockfordAlphabet + "*~$=U"
+
+// groupSize is the number of symbols between the dashes of a formatted code.
+const groupSize = 5
+
+// encodeBase32 encodes the data in Crockford base32 without padding.
+func encodeBase32(data []byte) string {
+	var b strings.Builder
+	var buffer uint32
+	bits := 0
+
+	for _, c := range data {
+		buffer = buffer<<8 | uint32(c)
+		bits += 8
+		for bits >= 5 {
+			bits -= 5
+			b.WriteByte(crockfordAlphabet[(buffer>>uint(bits))&31])
+		}
+	}
+	if bits > 0 {
+		b.WriteByte(crockfordAlphabet[(buffer<<uint(5-bits))&31])
+	}
+
+	return b.String()
+}
+
+// decodeBase32 decodes normalized Crockford base32 symbols into bytes, dropping the padding bits.
+func decodeBase32(symbols string) ([]byte, error) {
+	data := make([]byte, 0, len(symbols)*5/8)
+	var buffer uint32
+	bits := 0
+
+	for i := 0; i < len(symbols); i++ {
+		value := strings.IndexByte(crockfordAlphabet, symbols[i])
+		if value < 0 {
+			return nil, errors.New("invalid character in code")
+		}
+
+		buffer = buffer<<5 | uint32(value)
+		bits += 5
+		if bits >= 8 {
+			bits -= 8
+			data = append(data, byte(buffer>>uint(bits)))
+		}
+	}
+
+	return data, nil
+}
+
+// normalizeCode uppercases a typed code, maps the letters that are easily mistaken for digits and
+// drops dashes and spaces.
+func normalizeCode(code string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(code) {
+		switch r {
+		case '-', ' ', '\t':
+			continue
+		case 'I', 'L':
+			r = '1'
+		case 'O':
+			r = '0'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// checkSymbol returns the Crockford check symbol of normalized base32 symbols: the value they
+// encode modulo 37.
+func checkSymbol(symbols string) (byte, error) {
+	value := new(big.Int)
+	for i := 0; i < len(symbols); i++ {
+		digit := strings.IndexByte(crockfordAlphabet, symbols[i])
+		if digit < 0 {
+			return 0, errors.New("invalid character in code")
+		}
+		value.Mul(value, big.NewInt(32))
+		value.Add(value, big.NewInt(int64(digit)))
+	}
+
+	return crockfordChecks[new(big.Int).Mod(value, big.NewInt(37)).Int64()], nil
+}
+
+// formatCode encodes the data in Crockford base32, appends a check symbol and splits the result
+// into dash-separated groups.
+func formatCode(data []byte) string {
+	symbols := encodeBase32(data)
+	check, _ := checkSymbol(symbols)
+	symbols += string(check)
+
+	groups := make([]string, 0, len(symbols)/groupSize+1)
+	for len(symbols) > groupSize {
+		groups = append(groups, symbols[:groupSize])
+		symbols = symbols[groupSize:]
+	}
+	groups = append(groups, symbols)
+
+	return strings.Join(groups, "-")
+}
+
+// parseCode decodes a code written by formatCode, tolerating lowercase letters, mistaken letters,
+// and missing or extra dashes and spaces, and rejecting codes whose check symbol does not match.
+func parseCode(code string) ([]byte, error) {
+	symbols := normalizeCode(code)
+	if len(symbols) < 2 {
+		return nil, errors.New("code too short")
+	}
+
+	body, check := symbols[:len(symbols)-1], symbols[len(symbols)-1]
+	expected, err := checkSymbol(body)
+	if err != nil {
+		return nil, err
+	}
+	if check != expected {
+		return nil, errors.New("code mistyped")
+	}
+
+	return decodeBase32(body)
+}
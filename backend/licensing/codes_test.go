@@ -0,0 +1,103 @@
+/*
+ * File: codes_test.go
+ * File Created: Monday, 19th October 2026 4:26:19 pm
+ * Last Modified: Monday, 19th October 2026 4:26:19 pm
+ * Author: Akhil Datla
+ * Copyright © Akhil Datla 2023
+ */
+
+package licensing
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCodeRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"single byte", []byte{0x42}},
+		{"zero bytes", []byte{0, 0, 0, 0, 0}},
+		{"full block", []byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF}},
+		{"partial block", []byte("learnado")},
+		{"long", bytes.Repeat([]byte{0xA5, 0x3C, 0x0F}, 30)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			code := formatCode(test.data)
+			data, err := parseCode(code)
+			if err != nil {
+				t.Fatalf("parseCode(%q) failed: %v", code, err)
+			}
+			if !bytes.Equal(data, test.data) {
+				t.Errorf("parseCode(%q) = %x, want %x", code, data, test.data)
+			}
+		})
+	}
+}
+
+func TestParseCodeTolerance(t *testing.T) {
+	data := []byte("offline device 01")
+	code := formatCode(data)
+
+	tests := []struct {
+		name  string
+		typed string
+	}{
+		{"as printed", code},
+		{"lowercase", strings.ToLower(code)},
+		{"without dashes", strings.ReplaceAll(code, "-", "")},
+		{"spaces for dashes", strings.ReplaceAll(code, "-", " ")},
+		{"letters for digits", strings.NewReplacer("0", "O", "1", "l").Replace(code)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			parsed, err := parseCode(test.typed)
+			if err != nil {
+				t.Fatalf("parseCode(%q) failed: %v", test.typed, err)
+			}
+			if !bytes.Equal(parsed, data) {
+				t.Errorf("parseCode(%q) = %x, want %x", test.typed, parsed, data)
+			}
+		})
+	}
+}
+
+func TestParseCodeRejectsTypos(t *testing.T) {
+	symbols := normalizeCode(formatCode([]byte("hardware-1234")))
+
+	// Every single mistyped symbol changes the value modulo 37
+	for i := 0; i < len(symbols)-1; i++ {
+		for _, r := range crockfordAlphabet {
+			if byte(r) == symbols[i] {
+				continue
+			}
+			typed := symbols[:i] + string(r) + symbols[i+1:]
+			if _, err := parseCode(typed); err == nil {
+				t.Errorf("parseCode(%q) accepted a typo at %d", typed, i)
+			}
+		}
+	}
+
+	tests := []struct {
+		name  string
+		typed string
+	}{
+		{"empty", ""},
+		{"too short", "A"},
+		{"invalid character", "AB#CD-EFGH"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := parseCode(test.typed); err == nil {
+				t.Errorf("parseCode(%q) succeeded, want an error", test.typed)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+/*
+ * File: deliveries.go
+ * File Created: Monday, 19th October 2026 9:41:23 am
+ * Last Modified: Monday, 19th October 2026 2:48:58 pm
+ * Author: Akhil Datla
+ * Copyright © Akhil Datla 2023
+ */
+
+package licensing
+
+import (
+	"sort"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"main/backend/courses"
+	"main/backend/dbmanager"
+)
+
+// Delivery represents the package most recently built for a hardware ID.
+// A delivery becomes stale when one of its courses changes after it was built.
+type Delivery struct {
+	ID         string `storm:"id"`
+	HardwareID string `storm:"index"`
+	CourseIDs  []string
+	CreatedAt  time.Time
+	Stale      bool
+}
+
+func init() {
+	courses.OnCourseChange(markDeliveriesStale)
+}
+
+// recordDelivery stores a delivery of the courses to the hardware ID, replacing the previous one.
+func recordDelivery(hardwareID string, courseIDs []string, createdAt time.Time) error {
+	var previous []Delivery
+	dbmanager.GroupQuery("HardwareID", hardwareID, &previous)
+	for i := range previous {
+		dbmanager.Delete(&previous[i])
+	}
+
+	return dbmanager.Save(&Delivery{
+		ID:         uuid.NewV4().String(),
+		HardwareID: hardwareID,
+		CourseIDs:  courseIDs,
+		CreatedAt:  createdAt,
+	})
+}
+
+// markDeliveriesStale marks the deliveries built before the change that contain the changed course.
+func markDeliveriesStale(event courses.ChangeEvent) {
+	var deliveries []Delivery
+	dbmanager.QueryAll(&deliveries)
+
+	for _, delivery := range deliveries {
+		if delivery.Stale || !delivery.CreatedAt.Before(event.UpdatedAt) {
+			continue
+		}
+
+		for _, courseID := range delivery.CourseIDs {
+			if courseID == event.CourseID {
+				dbmanager.Update(&Delivery{ID: delivery.ID, Stale: true})
+				break
+			}
+		}
+	}
+}
+
+// markDeviceStale marks the delivery of the hardware ID stale.
+func markDeviceStale(hardwareID string) {
+	var deliveries []Delivery
+	dbmanager.GroupQuery("HardwareID", hardwareID, &deliveries)
+
+	for _, delivery := range deliveries {
+		dbmanager.Update(&Delivery{ID: delivery.ID, Stale: true})
+	}
+}
+
+// GetStaleDeliveries retrieves the deliveries whose devices should download their courses again,
+// limited to the devices holding entitlements of the organization with the given ID. Courses of
+// other organizations are left out of the deliveries.
+func GetStaleDeliveries(organizationID string) ([]Delivery, error) {
+	var entitlements []Entitlement
+	err := dbmanager.OrganizationQueryAll(organizationID, &entitlements)
+	if err != nil && !dbmanager.IsNotFound(err) {
+		return nil, err
+	}
+	courseIDs := make(map[string][]string)
+	for _, entitlement := range entitlements {
+		courseIDs[entitlement.HardwareID] = append(courseIDs[entitlement.HardwareID], entitlement.CourseID)
+	}
+
+	var deliveries []Delivery
+	err = dbmanager.QueryAll(&deliveries)
+
+	stale := make([]Delivery, 0)
+	for _, delivery := range deliveries {
+		entitled, ok := courseIDs[delivery.HardwareID]
+		if !delivery.Stale || !ok {
+			continue
+		}
+
+		delivered := make([]string, 0, len(delivery.CourseIDs))
+		for _, courseID := range delivery.CourseIDs {
+			if contains(entitled, courseID) {
+				delivered = append(delivered, courseID)
+			}
+		}
+		delivery.CourseIDs = delivered
+		stale = append(stale, delivery)
+	}
+
+	sort.Slice(stale, func(i, j int) bool {
+		return stale[i].HardwareID < stale[j].HardwareID
+	})
+
+	return stale, err
+}
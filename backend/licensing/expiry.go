@@ -0,0 +1,164 @@
+/*
+ * File: expiry.go
+ * File Created: Monday, 19th October 2026 9:52:50 am
+ * Last Modified: Monday, 19th October 2026 5:32:43 pm
+ * Author: Akhil Datla
+ * Copyright © Akhil Datla 2023
+ */
+
+package licensing
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"main/backend/dbmanager"
+)
+
+// LicenseFileName is the name of the file in every package that tells offline clients until when
+// each course may be shown.
+const LicenseFileName = "learnado-license.json"
+
+// LicenseFile represents the contents of the license file. Courses without an expiry are perpetual.
+// Revocations holds the signed revocation list of the courses the device must delete and Tokens the
+// signed license tokens of the device. Activations holds the signed activation list devices check
+// typed activation codes against.
+type LicenseFile struct {
+	HardwareID  string                `json:"hardwareID"`
+	IssuedAt    time.Time             `json:"issuedAt"`
+	Courses     map[string]*time.Time `json:"courses"`
+	Revocations string                `json:"revocations"`
+	Tokens      []string              `json:"tokens"`
+	Activations string                `json:"activations"`
+}
+
+// newLicenseFile prepares the license file for the delivered courses of the hardware ID, signing
+// its revocation list, license tokens and activation list.
+func newLicenseFile(hardwareID string, courseIDs []string, entitlements entitled, issuedAt time.Time) (LicenseFile, error) {
+	file := LicenseFile{
+		HardwareID: hardwareID,
+		IssuedAt:   issuedAt,
+		Courses:    make(map[string]*time.Time),
+	}
+
+	for _, courseID := range courseIDs {
+		expiresAt := entitlements.expiries[courseID]
+		if expiresAt.IsZero() {
+			file.Courses[courseID] = nil
+		} else {
+			file.Courses[courseID] = &expiresAt
+		}
+	}
+
+	var err error
+	file.Revocations, err = signRevocations(hardwareID, entitlements.revoked, issuedAt)
+	if err != nil {
+		return file, err
+	}
+
+	file.Tokens, err = issueTokens(hardwareID, entitlements.active, issuedAt)
+	if err != nil {
+		return file, err
+	}
+
+	file.Activations, err = signActivations(hardwareID, entitlements.active, issuedAt)
+	return file, err
+}
+
+// withLicenseFile returns a copy of the file map with the license file added.
+// The file map itself is left untouched so that builds can be shared between devices.
+func withLicenseFile(m map[string][]byte, file LicenseFile) (map[string][]byte, error) {
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string][]byte, len(m)+1)
+	for path, content := range m {
+		result[path] = content
+	}
+	result[LicenseFileName] = data
+
+	return result, nil
+}
+
+// GetEntitlements retrieves the entitlements of the organization held by the specified hardware ID,
+// latest expiry first.
+func GetEntitlements(organizationID, hardwareID string) ([]Entitlement, error) {
+	var entitlements []Entitlement
+	err := dbmanager.OrganizationGroupQuery(organizationID, "HardwareID", hardwareID, &entitlements)
+
+	sort.Slice(entitlements, func(i, j int) bool {
+		if entitlements[i].ExpiresAt.IsZero() != entitlements[j].ExpiresAt.IsZero() {
+			return entitlements[i].ExpiresAt.IsZero()
+		}
+		return entitlements[i].ExpiresAt.After(entitlements[j].ExpiresAt)
+	})
+
+	return entitlements, err
+}
+
+// RenewEntitlements extends the time-limited entitlements of the organization held by the hardware
+// ID for the course.
+// An empty hardware ID renews the course on every device and an empty course ID renews every
+// course of the device. Entitlements are extended either to the given date or by the given duration
+// counted from their current expiry, or from now if they already expired. Perpetual and revoked
+// entitlements are left as they are. It returns the number of renewed entitlements.
+func RenewEntitlements(organizationID, hardwareID, courseID string, validity Validity) (int, error) {
+	if hardwareID == "" && courseID == "" {
+		return 0, errors.New("hardware id or course id required")
+	}
+	if validity.Duration < 0 || (validity.Duration == 0 && validity.ExpiresAt.IsZero()) {
+		return 0, errors.New("invalid renewal period")
+	}
+	if validity.Duration > 0 && !validity.ExpiresAt.IsZero() {
+		return 0, errors.New("renewal date and duration cannot both be given")
+	}
+
+	var entitlements []Entitlement
+	var err error
+	if hardwareID != "" {
+		err = dbmanager.OrganizationGroupQuery(organizationID, "HardwareID", hardwareID, &entitlements)
+	} else {
+		err = dbmanager.OrganizationGroupQuery(organizationID, "CourseID", courseID, &entitlements)
+	}
+	if dbmanager.IsNotFound(err) {
+		return 0, errors.New("no entitlements found")
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	renewed := 0
+	for _, entitlement := range entitlements {
+		if entitlement.IsRevoked() || entitlement.ExpiresAt.IsZero() || (courseID != "" && entitlement.CourseID != courseID) {
+			continue
+		}
+
+		expiresAt := validity.ExpiresAt
+		if validity.Duration > 0 {
+			start := entitlement.ExpiresAt
+			if start.Before(now) {
+				start = now
+			}
+			expiresAt = start.Add(validity.Duration)
+		}
+
+		// Renewals only ever extend an entitlement
+		if !expiresAt.After(entitlement.ExpiresAt) {
+			continue
+		}
+
+		err = dbmanager.Update(&Entitlement{ID: entitlement.ID, ExpiresAt: expiresAt})
+		if err != nil {
+			return renewed, err
+		}
+		renewed++
+		recordEvent(entitlement.LicenseID, EventRenewed, entitlement.HardwareID, "until "+expiresAt.Format(time.RFC3339))
+	}
+
+	return renewed, nil
+}
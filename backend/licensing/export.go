@@ -0,0 +1,157 @@
+/*
+ * File: export.go
+ * File Created: Monday, 19th October 2026 7:40:24 am
+ * Last Modified: Monday, 19th October 2026 1:42:31 pm
+ * Author: Akhil Datla
+ * Copyright © Akhil Datla 2023
+ */
+
+package licensing
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"main/backend/courses"
+)
+
+// ExportEntry describes the package written for a single device.
+type ExportEntry struct {
+	HardwareID string            `json:"hardwareID"`
+	Package    string            `json:"package"`
+	CourseIDs  []string          `json:"courseIDs"`
+	Embargoed  []string          `json:"embargoed"`
+	Expired    []string          `json:"expired"`
+	Revoked    []string          `json:"revoked"`
+	Excluded   []string          `json:"excluded"`
+	Commits    map[string]string `json:"commits"`
+}
+
+// ExportIndex lists every package written by an export.
+type ExportIndex struct {
+	CreatedAt time.Time     `json:"createdAt"`
+	Devices   []ExportEntry `json:"devices"`
+	Failed    []string      `json:"failed"`
+}
+
+// ExportIndexName is the name of the index file written into the export directory.
+const ExportIndexName = "index.json"
+
+// ExportPackages writes one package per hardware ID plus an index file into outputDir.
+// Devices entitled to the same set of courses share a single website build.
+func ExportPackages(hardwareIDs []string, outputDir string) (ExportIndex, error) {
+	return exportPackages(hardwareIDs, outputDir, entitledCourses)
+}
+
+// ExportOrganizationPackages is like ExportPackages, but the packages only contain the courses of
+// the organization with the given ID.
+func ExportOrganizationPackages(organizationID string, hardwareIDs []string, outputDir string) (ExportIndex, error) {
+	return exportPackages(hardwareIDs, outputDir, func(hardwareID string, now time.Time) (entitled, error) {
+		return organizationEntitledCourses(organizationID, hardwareID, now)
+	})
+}
+
+// exportPackages writes the packages of the courses the lookup finds for each hardware ID.
+func exportPackages(hardwareIDs []string, outputDir string, lookup func(string, time.Time) (entitled, error)) (ExportIndex, error) {
+	index := ExportIndex{
+		CreatedAt: time.Now(),
+		Devices:   make([]ExportEntry, 0),
+		Failed:    make([]string, 0),
+	}
+
+	if len(hardwareIDs) == 0 {
+		return index, errors.New("no hardware ids")
+	}
+
+	err := os.MkdirAll(outputDir, 0755)
+	if err != nil {
+		return index, err
+	}
+
+	// Builds already made, keyed by their sorted course IDs
+	builds := make(map[string]map[string][]byte)
+	reports := make(map[string]courses.BuildReport)
+
+	for _, hardwareID := range hardwareIDs {
+		entitlements, err := lookup(hardwareID, index.CreatedAt)
+		if err != nil {
+			index.Failed = append(index.Failed, hardwareID)
+			continue
+		}
+
+		// Leave out courses that are not published yet or anymore
+		courseIDs, embargoed := courses.PartitionPublished(entitlements.courseIDs, index.CreatedAt)
+
+		sort.Strings(courseIDs)
+		key := strings.Join(courseIDs, ",")
+
+		// Build the website only once per distinct course set
+		m, ok := builds[key]
+		if !ok {
+			var report courses.BuildReport
+			m, report, err = courses.BuildWebsite(courseIDs)
+			if err != nil {
+				index.Failed = append(index.Failed, hardwareID)
+				continue
+			}
+			builds[key] = m
+			reports[key] = report
+		}
+
+		// Package the shared build with the license file for this device
+		file, err := newLicenseFile(hardwareID, courseIDs, entitlements, index.CreatedAt)
+		if err != nil {
+			index.Failed = append(index.Failed, hardwareID)
+			continue
+		}
+		licensed, err := withLicenseFile(m, file)
+		if err != nil {
+			index.Failed = append(index.Failed, hardwareID)
+			continue
+		}
+		gobFileName, err := courses.PackageWebsite(licensed, hardwareID, outputDir)
+		if err != nil {
+			index.Failed = append(index.Failed, hardwareID)
+			continue
+		}
+
+		index.Devices = append(index.Devices, ExportEntry{
+			HardwareID: hardwareID,
+			Package:    filepath.Base(gobFileName),
+			CourseIDs:  courseIDs,
+			Embargoed:  embargoed,
+			Expired:    entitlements.expired,
+			Revoked:    entitlements.revoked,
+			Excluded:   reports[key].Excluded,
+			Commits:    reports[key].Commits,
+		})
+
+		// Remember what the device received so that later course changes mark it stale
+		recordDelivery(hardwareID, courseIDs, index.CreatedAt)
+	}
+
+	// Write the index file next to the packages
+	indexBytes, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return index, err
+	}
+
+	err = os.WriteFile(filepath.Join(outputDir, ExportIndexName), indexBytes, 0666)
+	return index, err
+}
+
+// ExportGroupPackages writes one package per device in the named device group of the organization
+// into outputDir, containing the courses of the organization.
+func ExportGroupPackages(organizationID, groupName, outputDir string) (ExportIndex, error) {
+	group, err := GetDeviceGroup(organizationID, groupName)
+	if err != nil {
+		return ExportIndex{}, errors.New("invalid device group")
+	}
+
+	return ExportOrganizationPackages(group.OrganizationID, group.HardwareIDs, outputDir)
+}
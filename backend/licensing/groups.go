@@ -0,0 +1,82 @@
+/*
+ * File: groups.go
+ * File Created: Monday, 19th October 2026 7:40:24 am
+ * Last Modified: Monday, 19th October 2026 2:48:58 pm
+ * Author: Akhil Datla
+ * Copyright © Akhil Datla 2023
+ */
+
+package licensing
+
+import (
+	"errors"
+
+	uuid "github.com/satori/go.uuid"
+
+	"main/backend/dbmanager"
+)
+
+// DeviceGroup represents a named set of hardware IDs, such as a classroom.
+type DeviceGroup struct {
+	ID             string `storm:"id"`
+	Name           string `storm:"index"`
+	OrganizationID string `storm:"index"`
+	HardwareIDs    []string
+}
+
+// CreateDeviceGroup creates a new device group of the organization with the given name and hardware IDs.
+func CreateDeviceGroup(organizationID, name string, hardwareIDs []string) (string, error) {
+	if name == "" {
+		return "", errors.New("invalid group name")
+	}
+	if _, err := GetDeviceGroup(organizationID, name); err == nil {
+		return "", errors.New("group name already in use")
+	}
+
+	group := &DeviceGroup{
+		ID:             uuid.NewV4().String(),
+		Name:           name,
+		OrganizationID: organizationID,
+		HardwareIDs:    hardwareIDs,
+	}
+
+	// Save the device group to the database
+	err := dbmanager.Save(group)
+	return group.ID, err
+}
+
+// GetDeviceGroup retrieves a device group of the organization with the given ID by its name.
+func GetDeviceGroup(organizationID, name string) (DeviceGroup, error) {
+	var group DeviceGroup
+	err := dbmanager.OrganizationQuery(organizationID, "Name", name, &group)
+	return group, err
+}
+
+// GetAllDeviceGroups retrieves all device groups.
+func GetAllDeviceGroups() ([]DeviceGroup, error) {
+	var groups []DeviceGroup
+	err := dbmanager.QueryAll(&groups)
+	return groups, err
+}
+
+// GetOrganizationDeviceGroups retrieves the device groups of the organization with the given ID.
+func GetOrganizationDeviceGroups(organizationID string) ([]DeviceGroup, error) {
+	var groups []DeviceGroup
+	err := dbmanager.OrganizationQueryAll(organizationID, &groups)
+	if dbmanager.IsNotFound(err) {
+		return make([]DeviceGroup, 0), nil
+	}
+	return groups, err
+}
+
+// DeleteDeviceGroup deletes a device group of the organization with the given ID.
+func DeleteDeviceGroup(organizationID, id string) error {
+	var group DeviceGroup
+	err := dbmanager.OrganizationQuery(organizationID, "ID", id, &group)
+	if err != nil {
+		return errors.New("invalid device group")
+	}
+
+	err = dbmanager.Delete(&group)
+	return err
+}
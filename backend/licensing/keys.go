@@ -0,0 +1,175 @@
+/*
+ * File: keys.go
+ * File Created: Monday, 19th October 2026 10:58:32 am
+ * Last Modified: Monday, 19th October 2026 11:52:00 am
+ * Author: Akhil Datla
+ * Copyright © Akhil Datla 2023
+ */
+
+package licensing
+
+import (
+	"crypto/rand"
+	"errors"
+	"strings"
+
+	uuid "github.com/satori/go.uuid"
+
+	"main/backend/dbmanager"
+)
+
+const (
+	// minKeyLength and maxKeyLength bound the number of random symbols in a license key.
+	minKeyLength = 10
+	maxKeyLength = 40
+
+	// maxPrefixLength is the maximum length of a license key prefix.
+	maxPrefixLength = 8
+)
+
+// Errors of license keys that do not belong to any license.
+var (
+	ErrInvalidKey  = errors.New("invalid license key")
+	ErrMistypedKey = errors.New("license key mistyped")
+)
+
+// KeyFormat represents the format of generated license keys: an optional prefix followed by
+// Length random Crockford base32 symbols and a check symbol, printed in groups of five.
+type KeyFormat struct {
+	Prefix string `json:"prefix"`
+	Length int    `json:"length"`
+}
+
+// DefaultKeyFormat is the format of license keys for courses without a format of their own.
+// With the check symbol its keys fill four groups of five.
+var DefaultKeyFormat = KeyFormat{Length: 19}
+
+// CourseKeyFormat represents the license key format chosen for a course.
+type CourseKeyFormat struct {
+	CourseID string `storm:"id"`
+	Format   KeyFormat
+}
+
+// IsZero reports whether no format was given.
+func (f KeyFormat) IsZero() bool {
+	return f.Prefix == "" && f.Length == 0
+}
+
+// Validate checks that the prefix only uses symbols of the key alphabet and that the length is in range.
+func (f KeyFormat) Validate() error {
+	if len(f.Prefix) > maxPrefixLength {
+		return errors.New("license key prefix too long")
+	}
+	for i := 0; i < len(f.Prefix); i++ {
+		if strings.IndexByte(crockfordAlphabet, f.Prefix[i]) < 0 {
+			return errors.New("license key prefix may only use digits and the letters of the key alphabet")
+		}
+	}
+	if f.Length < minKeyLength || f.Length > maxKeyLength {
+		return errors.New("invalid license key length")
+	}
+	return nil
+}
+
+// SetCourseKeyFormat sets the format of the license keys generated for the course with the given ID.
+func SetCourseKeyFormat(courseID string, format KeyFormat) error {
+	format.Prefix = strings.ToUpper(format.Prefix)
+	if err := format.Validate(); err != nil {
+		return err
+	}
+
+	return dbmanager.Save(&CourseKeyFormat{CourseID: courseID, Format: format})
+}
+
+// GetCourseKeyFormat retrieves the format of the license keys generated for the course with the given ID.
+func GetCourseKeyFormat(courseID string) KeyFormat {
+	var format CourseKeyFormat
+	if err := dbmanager.Query("CourseID", courseID, &format); err != nil {
+		return DefaultKeyFormat
+	}
+	return format.Format
+}
+
+// luhnSymbol returns the Luhn mod 32 check symbol of normalized base32 symbols, which catches every
+// single mistyped symbol and most swaps of neighbouring symbols.
+func luhnSymbol(symbols string) byte {
+	const n = len(crockfordAlphabet)
+
+	factor := 2
+	sum := 0
+	for i := len(symbols) - 1; i >= 0; i-- {
+		addend := factor * strings.IndexByte(crockfordAlphabet, symbols[i])
+		factor = 3 - factor
+		sum += addend/n + addend%n
+	}
+
+	return crockfordAlphabet[(n-sum%n)%n]
+}
+
+// newLicenseKey generates a random license key in the format, without dashes.
+func newLicenseKey(format KeyFormat) (string, error) {
+	random := make([]byte, format.Length)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+
+	// Every byte maps to one symbol without bias, as 256 is a multiple of 32
+	symbols := []byte(format.Prefix)
+	for _, b := range random {
+		symbols = append(symbols, crockfordAlphabet[b%32])
+	}
+
+	return string(symbols) + string(luhnSymbol(string(symbols))), nil
+}
+
+// FormatLicenseKey prints a license key for people: the prefix followed by groups of five symbols.
+func FormatLicenseKey(key, prefix string) string {
+	if isLegacyKey(key) || !strings.HasPrefix(key, prefix) {
+		return key
+	}
+
+	body := key[len(prefix):]
+	groups := make([]string, 0, len(body)/groupSize+2)
+	if prefix != "" {
+		groups = append(groups, prefix)
+	}
+	for len(body) > groupSize {
+		groups = append(groups, body[:groupSize])
+		body = body[groupSize:]
+	}
+	groups = append(groups, body)
+
+	return strings.Join(groups, "-")
+}
+
+// ParseLicenseKey turns a typed license key into the form it is stored in. Case, dashes, spaces and
+// letters mistaken for digits are tolerated, and keys with a wrong check symbol are rejected without
+// looking them up. Keys issued before this format are accepted as they are.
+func ParseLicenseKey(input string) (string, error) {
+	input = strings.TrimSpace(input)
+	if isLegacyKey(strings.ToLower(input)) {
+		return strings.ToLower(input), nil
+	}
+
+	key := normalizeCode(input)
+	if len(key) < minKeyLength+1 || len(key) > maxPrefixLength+maxKeyLength+1 {
+		return "", ErrInvalidKey
+	}
+	for i := 0; i < len(key); i++ {
+		if strings.IndexByte(crockfordAlphabet, key[i]) < 0 {
+			return "", ErrInvalidKey
+		}
+	}
+
+	if luhnSymbol(key[:len(key)-1]) != key[len(key)-1] {
+		return "", ErrMistypedKey
+	}
+
+	return key, nil
+}
+
+// isLegacyKey reports whether the key is a UUID, as license keys were before the current format.
+func isLegacyKey(key string) bool {
+	id, err := uuid.FromString(key)
+	return err == nil && id.String() == key
+}
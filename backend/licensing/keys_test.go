@@ -0,0 +1,99 @@
+/*
+ * File: keys_test.go
+ * File Created: Monday, 19th October 2026 4:37:32 pm
+ * Last Modified: Monday, 19th October 2026 4:37:32 pm
+ * Author: Akhil Datla
+ * Copyright © Akhil Datla 2023
+ */
+
+package licensing
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseLicenseKeyNormalizes(t *testing.T) {
+	key := "7K3MQD9XTP2WB4RHN60" + string(luhnSymbol("7K3MQD9XTP2WB4RHN60"))
+	prefixed := "SCH7K3MQD9XT1" + string(luhnSymbol("SCH7K3MQD9XT1"))
+
+	tests := []struct {
+		name  string
+		typed string
+		want  string
+	}{
+		{"as printed", FormatLicenseKey(key, ""), key},
+		{"without dashes", key, key},
+		{"lowercase", strings.ToLower(FormatLicenseKey(key, "")), key},
+		{"spaces for dashes", strings.ReplaceAll(FormatLicenseKey(key, ""), "-", " "), key},
+		{"surrounding space", "  " + FormatLicenseKey(key, "") + "\t", key},
+		{"letter O for zero", strings.ReplaceAll(key, "0", "O"), key},
+		{"prefix", FormatLicenseKey(prefixed, "SCH"), prefixed},
+		{"letters I and L for one", strings.ReplaceAll(FormatLicenseKey(prefixed, "SCH"), "1", "i"), prefixed},
+		{"legacy key", "7B0E0C1E-5A4D-4B0C-9A57-3C2F1E8D9A60", "7b0e0c1e-5a4d-4b0c-9a57-3c2f1e8d9a60"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseLicenseKey(test.typed)
+			if err != nil {
+				t.Fatalf("ParseLicenseKey(%q) failed: %v", test.typed, err)
+			}
+			if got != test.want {
+				t.Errorf("ParseLicenseKey(%q) = %q, want %q", test.typed, got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseLicenseKeyRejects(t *testing.T) {
+	key := "7K3MQD9XTP2WB4RHN60" + string(luhnSymbol("7K3MQD9XTP2WB4RHN60"))
+
+	tests := []struct {
+		name  string
+		typed string
+		want  error
+	}{
+		{"empty", "", ErrInvalidKey},
+		{"too short", key[:minKeyLength], ErrInvalidKey},
+		{"too long", strings.Repeat("7", maxPrefixLength+maxKeyLength+2), ErrInvalidKey},
+		{"invalid character", key[:5] + "U" + key[6:], ErrInvalidKey},
+		{"wrong check symbol", key[:len(key)-1] + string(otherSymbol(key[len(key)-1])), ErrMistypedKey},
+		{"swapped symbols", key[:1] + key[2:3] + key[1:2] + key[3:], ErrMistypedKey},
+		{"missing symbol", key[:4] + key[5:], ErrMistypedKey},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ParseLicenseKey(test.typed)
+			if !errors.Is(err, test.want) {
+				t.Errorf("ParseLicenseKey(%q) = %v, want %v", test.typed, err, test.want)
+			}
+		})
+	}
+}
+
+func TestLuhnSymbolCatchesTypos(t *testing.T) {
+	formats := []KeyFormat{DefaultKeyFormat, {Prefix: "SCH", Length: minKeyLength}, {Length: maxKeyLength}}
+
+	for _, format := range formats {
+		key := testLicenseKey(t, format)
+		if _, err := ParseLicenseKey(key); err != nil {
+			t.Fatalf("ParseLicenseKey(%q) rejected a generated key: %v", key, err)
+		}
+
+		// Every single mistyped symbol is caught, including in the check symbol itself
+		for i := 0; i < len(key); i++ {
+			for _, r := range crockfordAlphabet {
+				if byte(r) == key[i] {
+					continue
+				}
+				typed := key[:i] + string(r) + key[i+1:]
+				if _, err := ParseLicenseKey(typed); !errors.Is(err, ErrMistypedKey) {
+					t.Errorf("ParseLicenseKey(%q) = %v, want %v", typed, err, ErrMistypedKey)
+				}
+			}
+		}
+	}
+}
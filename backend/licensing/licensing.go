@@ -1,7 +1,7 @@
 /*
  * File: licensing.go
  * File Created: Sunday, 11th June 2023 9:57:15 pm
- * Last Modified: Friday, 23rd June 2023 12:43:21 am
+ * Last Modified: Monday, 19th October 2026 5:21:34 pm
  * Author: Akhil Datla
  * Copyright © Akhil Datla 2023
  */
@@ -10,6 +10,10 @@ package licensing
 
 import (
 	"errors"
+	"os"
+	"strings"
+	"sync"
+	"time"
 
 	uuid "github.com/satori/go.uuid"
 
@@ -18,104 +22,547 @@ import (
 )
 
 // License represents a license object.
+// A license with an ExpiresAt date or a Duration grants time-limited entitlements, and it can be
+// activated on as many hardware IDs as it has seats. A license for a bundle has a BundleID instead
+// of a CourseID and entitles to every course of the bundle.
 type License struct {
-	ID       string `storm:"id"`
-	CourseID string `storm:"index"`
+	ID             string `storm:"id"`
+	CourseID       string `storm:"index"`
+	BundleID       string `storm:"index"`
+	OrganizationID string `storm:"index"`
+	ExpiresAt      time.Time
+	Duration       time.Duration
+	Seats          int
+	Prefix         string
+	BatchID        string `storm:"index"`
+	Status         string
+	RevokedAt      time.Time
 }
 
 // Entitlement represents an entitlement object.
+// A zero ExpiresAt makes the entitlement perpetual. An entitlement belongs to the organization of
+// its license.
 type Entitlement struct {
-	ID         string `storm:"id"`
-	CourseID   string `storm:"index"`
-	HardwareID string `storm:"index"`
+	ID             string `storm:"id"`
+	CourseID       string `storm:"index"`
+	HardwareID     string `storm:"index"`
+	LicenseID      string `storm:"index"`
+	OrganizationID string `storm:"index"`
+	ActivatedAt    time.Time
+	ExpiresAt      time.Time
+	Transfers      int
+	TransferAt     time.Time
+	RevokedAt      time.Time
 }
 
-// GenerateLicense generates a license for the specified course ID.
+// Validity represents the validity period of a license: a fixed expiry date, a duration counted
+// from activation, or both, in which case the entitlement ends at whichever comes first.
+type Validity struct {
+	ExpiresAt time.Time
+	Duration  time.Duration
+}
+
+// Terms represents the validity period, number of seats and key format of a license.
+// A zero number of seats means a single seat and a zero key format the format of the course.
+type Terms struct {
+	Validity
+	Seats     int
+	KeyFormat KeyFormat
+}
+
+// registerMutex serializes activations so that a license never exceeds its seats.
+var registerMutex sync.Mutex
+
+// IsExpired reports whether the entitlement has expired at the given time.
+func (e Entitlement) IsExpired(now time.Time) bool {
+	return !e.ExpiresAt.IsZero() && !now.Before(e.ExpiresAt)
+}
+
+// IsRevoked reports whether the entitlement was revoked.
+func (e Entitlement) IsRevoked() bool {
+	return !e.RevokedAt.IsZero()
+}
+
+// GenerateLicense generates a perpetual single-seat license for the specified course ID.
 func GenerateLicense(courseID string) (string, error) {
-	var course courses.Course
-	err := dbmanager.Query("ID", courseID, &course)
+	return GenerateLicenseWithTerms(courseID, Terms{})
+}
+
+// GenerateLicenseWithTerms generates a license for the specified course ID with the given terms.
+func GenerateLicenseWithTerms(courseID string, terms Terms) (string, error) {
+	licenses, err := GenerateLicensesWithTerms(courseID, 1, terms)
 	if err != nil {
-		return "", errors.New("invalid course id")
+		return "", err
+	}
+	return licenses[0], nil
+}
+
+// GenerateLicenses generates multiple perpetual single-seat licenses for the specified course ID and number.
+func GenerateLicenses(courseID string, num int) ([]string, error) {
+	return GenerateLicensesWithTerms(courseID, num, Terms{})
+}
+
+// GenerateLicensesWithTerms generates multiple licenses for the specified course ID and number with the given terms.
+func GenerateLicensesWithTerms(courseID string, num int, terms Terms) ([]string, error) {
+	_, licenses, err := GenerateBatch(LicenseBatch{CourseID: courseID}, num, terms)
+	return licenses, err
+}
+
+// checkTerms checks the terms and fills in the number of seats and the key format of the course.
+func checkTerms(courseID string, terms Terms) (Terms, error) {
+	if terms.Duration < 0 {
+		return terms, errors.New("invalid license duration")
+	}
+	if terms.Seats < 0 {
+		return terms, errors.New("invalid number of seats")
+	}
+	if terms.Seats == 0 {
+		terms.Seats = 1
+	}
+
+	// Use the key format of the course unless the terms give one
+	if terms.KeyFormat.IsZero() {
+		terms.KeyFormat = GetCourseKeyFormat(courseID)
+	}
+	terms.KeyFormat.Prefix = strings.ToUpper(terms.KeyFormat.Prefix)
+
+	return terms, terms.KeyFormat.Validate()
+}
+
+// newLicense generates and saves a license for the course or bundle of the batch with checked terms
+// and returns its key as printed for people.
+func newLicense(batch LicenseBatch, terms Terms) (string, error) {
+	// Generate a new license key that is not in use yet
+	var key string
+	var err error
+	for {
+		key, err = newLicenseKey(terms.KeyFormat)
+		if err != nil {
+			return "", err
+		}
+		var existing License
+		if dbmanager.Query("ID", key, &existing) != nil {
+			break
+		}
 	}
 
-	// Generate a new license ID
 	license := &License{
-		ID:       uuid.NewV4().String(),
-		CourseID: courseID,
+		ID:             key,
+		CourseID:       batch.CourseID,
+		BundleID:       batch.BundleID,
+		OrganizationID: batch.OrganizationID,
+		BatchID:        batch.ID,
+		ExpiresAt:      terms.ExpiresAt,
+		Duration:       terms.Duration,
+		Seats:          terms.Seats,
+		Prefix:         terms.KeyFormat.Prefix,
+		Status:         StatusIssued,
 	}
 
 	// Save the license to the database
 	err = dbmanager.Save(license)
-	return license.ID, err
+	if err != nil {
+		return "", err
+	}
+
+	recordEvent(license.ID, EventIssued, "", "")
+	return FormatLicenseKey(license.ID, license.Prefix), nil
 }
 
-// GenerateLicenses generates multiple licenses for the specified course ID and number.
-func GenerateLicenses(courseID string, num int) ([]string, error) {
-	licenses := make([]string, 0)
-	for i := 0; i < num; i++ {
-		// Generate a license for each iteration
-		licenseID, err := GenerateLicense(courseID)
+// Activation represents the result of registering a license on a device. Token is empty while
+// every activated course is embargoed; Embargoed lists the courses that are not published yet,
+// which the device can ask for again once they are.
+type Activation struct {
+	RemainingSeats int      `json:"remainingSeats"`
+	Token          string   `json:"token"`
+	Embargoed      []string `json:"embargoed"`
+}
+
+// RegisterLicense registers a license with the specified license ID and hardware ID and returns
+// the number of seats of the license that remain together with a signed license token for the
+// device. Registering a hardware ID that already holds a seat of the license does not take
+// another seat, but entitles it to courses added to the bundle of the license since.
+func RegisterLicense(licenseID, hardwareID string) (Activation, error) {
+	registerMutex.Lock()
+	defer registerMutex.Unlock()
+
+	license, err := findLicense(licenseID)
+	if err != nil {
+		return Activation{}, err
+	}
+
+	// Revoked licenses and licenses with a fixed expiry date that passed cannot be activated
+	now := time.Now()
+	switch license.StatusAt(now) {
+	case StatusRevoked:
+		return Activation{}, reject(license, hardwareID, "license revoked")
+	case StatusExpired:
+		return Activation{}, reject(license, hardwareID, "license expired")
+	}
+
+	// Check the seats already taken
+	activations, err := GetLicenseActivations(license.ID)
+	if err != nil {
+		return Activation{}, err
+	}
+	taken := seatHolders(activations)
+	if contains(taken, hardwareID) {
+		return reactivate(license, hardwareID, activations, license.seats()-len(taken), now)
+	}
+	if len(taken) >= license.seats() {
+		return Activation{}, reject(license, hardwareID, "no seats left")
+	}
+
+	courseIDs, err := license.courseIDs()
+	if err != nil {
+		return Activation{}, err
+	}
+	if len(courseIDs) == 0 {
+		return Activation{}, reject(license, hardwareID, "license grants no courses")
+	}
+
+	// Courses revoked for the device, or for every device, are not granted again
+	revoked, err := revokedCourses(license, hardwareID, activations)
+	if err != nil {
+		return Activation{}, err
+	}
+	granted := make([]string, 0, len(courseIDs))
+	for _, courseID := range courseIDs {
+		if !contains(revoked, courseID) {
+			granted = append(granted, courseID)
+		}
+	}
+	if len(granted) == 0 {
+		return Activation{}, reject(license, hardwareID, "license revoked on this device")
+	}
+	courseIDs = granted
+
+	// Create an entitlement to every course of the registered license
+	entitlements := make([]Entitlement, 0, len(courseIDs))
+	for _, courseID := range courseIDs {
+		entitlement := Entitlement{
+			ID:             uuid.NewV4().String(),
+			CourseID:       courseID,
+			HardwareID:     hardwareID,
+			LicenseID:      license.ID,
+			OrganizationID: license.OrganizationID,
+			ActivatedAt:    now,
+			ExpiresAt:      expiry(license, now),
+		}
+
+		// Save the entitlement to the database
+		err = dbmanager.Save(&entitlement)
 		if err != nil {
-			return nil, err
+			return Activation{}, err
 		}
-		licenses = append(licenses, licenseID)
+		entitlements = append(entitlements, entitlement)
 	}
-	return licenses, nil
+
+	// Record the activation
+	if license.Status != StatusActivated {
+		dbmanager.Update(&License{ID: license.ID, Status: StatusActivated})
+	}
+	recordEvent(license.ID, EventActivated, hardwareID, "")
+
+	return activate(hardwareID, entitlements, license.seats()-len(taken)-1, now)
 }
 
-// RegisterLicense registers a license with the specified license ID and hardware ID.
-func RegisterLicense(licenseID, hardwareID string) error {
-	var license License
-	err := dbmanager.Query("ID", licenseID, &license)
+// reactivate returns the activation of a hardware ID that already holds a seat of the license,
+// entitling it to courses added to the bundle of the license since it was activated.
+func reactivate(license License, hardwareID string, activations []Entitlement, remainingSeats int, now time.Time) (Activation, error) {
+	if license.BundleID != "" {
+		bundle, err := GetBundle(license.BundleID)
+		if err != nil {
+			return Activation{}, err
+		}
+		if _, err := entitleBundle(license, bundle, hardwareID, activations, now); err != nil {
+			return Activation{}, err
+		}
+		if activations, err = GetLicenseActivations(license.ID); err != nil {
+			return Activation{}, err
+		}
+	}
+
+	// Courses revoked for the device are left out even where an entitlement was not marked
+	revokedCourseIDs, err := revokedCourses(license, hardwareID, activations)
 	if err != nil {
-		return errors.New("invalid license key")
+		return Activation{}, err
 	}
 
-	// Create an entitlement for the registered license
-	entitlement := &Entitlement{
-		ID:         uuid.NewV4().String(),
-		CourseID:   license.CourseID,
-		HardwareID: hardwareID,
+	held := make([]Entitlement, 0)
+	revoked := false
+	for _, activation := range activations {
+		if activation.HardwareID != hardwareID {
+			continue
+		}
+		if activation.IsRevoked() || contains(revokedCourseIDs, activation.CourseID) {
+			revoked = true
+			continue
+		}
+		held = append(held, activation)
+	}
+	if len(held) == 0 && revoked {
+		return Activation{}, reject(license, hardwareID, "license revoked on this device")
 	}
 
-	// Save the entitlement to the database
-	err = dbmanager.Save(entitlement)
+	return activate(hardwareID, held, remainingSeats, now)
+}
+
+// reject records a refused activation of the license and returns the reason as an error.
+func reject(license License, hardwareID, reason string) error {
+	recordEvent(license.ID, EventRejected, hardwareID, reason)
+	return errors.New(reason)
+}
 
-	// Delete the registered license
-	dbmanager.Delete(&license)
+// activate returns the activation of the entitlements of the hardware ID through one license with
+// a license token for them. Devices whose entitlements all expired get an error rather than an
+// expired token.
+func activate(hardwareID string, entitlements []Entitlement, remainingSeats int, now time.Time) (Activation, error) {
+	expired := len(entitlements) > 0
+	for _, entitlement := range entitlements {
+		if !entitlement.IsExpired(now) {
+			expired = false
+		}
+	}
+	if expired {
+		return Activation{}, errors.New("license expired on this device")
+	}
 
-	return err
+	tokens, err := issueTokens(hardwareID, entitlements, now)
+	if err != nil {
+		return Activation{}, err
+	}
+
+	// Courses pre-loaded before their release are activated without a token for them
+	courseIDs := make([]string, 0, len(entitlements))
+	for _, entitlement := range entitlements {
+		if !entitlement.IsRevoked() && !entitlement.IsExpired(now) && !contains(courseIDs, entitlement.CourseID) {
+			courseIDs = append(courseIDs, entitlement.CourseID)
+		}
+	}
+	_, embargoed := courses.PartitionPublished(courseIDs, now)
+
+	activation := Activation{RemainingSeats: remainingSeats, Embargoed: embargoed}
+	if len(tokens) > 0 {
+		activation.Token = tokens[0]
+	}
+	return activation, nil
 }
 
-// RevokeLicense revokes a license with the specified license ID.
-func RevokeLicense(licenseID string) error {
+// GetLicense retrieves the license with the given key as typed by a person.
+func GetLicense(key string) (License, error) {
+	return findLicense(key)
+}
+
+// findLicense retrieves the license with the given key as typed by a person.
+func findLicense(key string) (License, error) {
+	id, err := ParseLicenseKey(key)
+	if err != nil {
+		return License{}, err
+	}
+
 	var license License
-	err := dbmanager.Query("ID", licenseID, &license)
+	err = dbmanager.Query("ID", id, &license)
+	if err != nil {
+		return License{}, ErrInvalidKey
+	}
+
+	return license, nil
+}
+
+// expiry returns when an entitlement activated at the given time from the license expires.
+func expiry(license License, activatedAt time.Time) time.Time {
+	expiresAt := license.ExpiresAt
+	if license.Duration > 0 {
+		end := activatedAt.Add(license.Duration)
+		if expiresAt.IsZero() || end.Before(expiresAt) {
+			expiresAt = end
+		}
+	}
+	return expiresAt
+}
+
+// RevokeLicense revokes a license with the specified license ID so that it cannot be activated
+// anymore. Devices that activated it keep their courses until their entitlements are revoked.
+func RevokeLicense(licenseID string) error {
+	license, err := findLicense(licenseID)
 	if err != nil {
-		return errors.New("invalid license key")
+		return err
 	}
 
-	// Delete the revoked license from the database
-	err = dbmanager.Delete(&license)
+	return revokeLicense(license, "")
+}
+
+// Download represents a package built for a hardware ID.
+type Download struct {
+	Package     string
+	Embargoed   []string
+	Expired     []string
+	Revoked     []string
+	Revocations string
+	Tokens      []string
+	Report      courses.BuildReport
+}
 
-	return err
+// entitled represents the courses a hardware ID is entitled to at a given time.
+type entitled struct {
+	courseIDs []string
+	expiries  map[string]time.Time
+	expired   []string
+	revoked   []string
+	active    []Entitlement
 }
 
 // DownloadCourses downloads courses for the specified hardware ID.
-func DownloadCourses(hardwareID string) (string, error) {
+// Entitled courses outside their publication window are reported as embargoed and courses whose
+// entitlements expired or were revoked are reported as such instead of delivered. Revoked courses
+// are also listed in a signed revocation list telling the device to delete them.
+func DownloadCourses(hardwareID string) (Download, error) {
+	now := time.Now()
+	entitlements, err := entitledCourses(hardwareID, now)
+	if err != nil {
+		return Download{}, err
+	}
+
+	return buildDownload(hardwareID, entitlements, now)
+}
+
+// buildDownload builds the package of the entitled courses for the hardware ID.
+func buildDownload(hardwareID string, entitlements entitled, now time.Time) (Download, error) {
+	// Leave out courses that are not published yet or anymore
+	published, embargoed := courses.PartitionPublished(entitlements.courseIDs, now)
+
+	// Sign the list of courses the device must delete and the license tokens of the device
+	file, err := newLicenseFile(hardwareID, published, entitlements, now)
+	if err != nil {
+		return Download{}, err
+	}
+
+	// Build a website for the course IDs
+	m, report, err := courses.BuildWebsite(published)
+	if err != nil {
+		return Download{}, err
+	}
+
+	// Package the website together with the license file for the hardware ID
+	m, err = withLicenseFile(m, file)
+	if err != nil {
+		return Download{}, err
+	}
+	gobFileName, err := courses.PackageWebsite(m, hardwareID, "")
+	if err != nil {
+		return Download{}, err
+	}
+
+	// Remember what the device received so that later course changes mark it stale
+	recordDelivery(hardwareID, published, now)
+
+	return Download{
+		Package:     gobFileName,
+		Embargoed:   embargoed,
+		Expired:     entitlements.expired,
+		Revoked:     entitlements.revoked,
+		Revocations: file.Revocations,
+		Tokens:      file.Tokens,
+		Report:      report,
+	}, nil
+}
+
+// entitledCourses retrieves the IDs of the courses the specified hardware ID is entitled to at the
+// given time together with when each entitlement expires, and the IDs of the courses whose
+// entitlements have all expired or were revoked. A course without an expiry is entitled perpetually.
+func entitledCourses(hardwareID string, now time.Time) (entitled, error) {
 	var entitlements []Entitlement
 	err := dbmanager.GroupQuery("HardwareID", hardwareID, &entitlements)
 	if err != nil {
-		return "", err
+		return entitled{}, err
+	}
+
+	return summarizeEntitlements(entitlements, now), nil
+}
+
+// organizationEntitledCourses is like entitledCourses, but only considers the entitlements of the
+// organization with the given ID.
+func organizationEntitledCourses(organizationID, hardwareID string, now time.Time) (entitled, error) {
+	var entitlements []Entitlement
+	err := dbmanager.OrganizationGroupQuery(organizationID, "HardwareID", hardwareID, &entitlements)
+	if err != nil {
+		return entitled{}, err
+	}
+
+	return summarizeEntitlements(entitlements, now), nil
+}
+
+// summarizeEntitlements sorts the entitlements of a hardware ID into the courses it is entitled to
+// at the given time and the courses it lost.
+func summarizeEntitlements(entitlements []Entitlement, now time.Time) entitled {
+	result := entitled{
+		courseIDs: make([]string, 0),
+		expiries:  make(map[string]time.Time),
+		expired:   make([]string, 0),
+		revoked:   make([]string, 0),
+		active:    make([]Entitlement, 0),
+	}
+
+	// Keep the latest expiry of every course with an active entitlement
+	for _, entitlement := range entitlements {
+		if entitlement.IsExpired(now) || entitlement.IsRevoked() {
+			continue
+		}
+		result.active = append(result.active, entitlement)
+
+		expiresAt, ok := result.expiries[entitlement.CourseID]
+		if !ok {
+			result.courseIDs = append(result.courseIDs, entitlement.CourseID)
+			result.expiries[entitlement.CourseID] = entitlement.ExpiresAt
+		} else if !expiresAt.IsZero() && (entitlement.ExpiresAt.IsZero() || entitlement.ExpiresAt.After(expiresAt)) {
+			result.expiries[entitlement.CourseID] = entitlement.ExpiresAt
+		}
 	}
 
-	courseIDs := make([]string, 0)
+	// Report courses that are only entitled through revoked or expired entitlements
+	for _, entitlement := range entitlements {
+		if _, ok := result.expiries[entitlement.CourseID]; ok || !entitlement.IsRevoked() || contains(result.revoked, entitlement.CourseID) {
+			continue
+		}
+		result.revoked = append(result.revoked, entitlement.CourseID)
+	}
 	for _, entitlement := range entitlements {
-		courseIDs = append(courseIDs, entitlement.CourseID)
+		if _, ok := result.expiries[entitlement.CourseID]; ok || contains(result.revoked, entitlement.CourseID) || contains(result.expired, entitlement.CourseID) {
+			continue
+		}
+		result.expired = append(result.expired, entitlement.CourseID)
+	}
+
+	return result
+}
+
+// contains reports whether the slice contains the value.
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// DownloadCourseVolumes builds the package of the courses of the organization for the specified
+// hardware ID and splits it into volumes of at most volumeSize bytes inside outputDir.
+func DownloadCourseVolumes(organizationID, hardwareID, outputDir string, volumeSize int64) (courses.VolumeManifest, error) {
+	now := time.Now()
+	entitlements, err := organizationEntitledCourses(organizationID, hardwareID, now)
+	if err != nil {
+		return courses.VolumeManifest{}, err
+	}
+
+	download, err := buildDownload(hardwareID, entitlements, now)
+	if err != nil {
+		return courses.VolumeManifest{}, err
 	}
 
-	// Generate a website for the hardware ID and course IDs
-	gobFileName := courses.GenerateWebsite(hardwareID, courseIDs)
+	defer os.Remove(download.Package)
 
-	return gobFileName, nil
+	// Split the package into volumes
+	return courses.SplitPackage(download.Package, outputDir, volumeSize)
 }
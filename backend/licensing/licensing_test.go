@@ -0,0 +1,54 @@
+/*
+ * File: licensing_test.go
+ * File Created: Monday, 19th October 2026 5:21:34 pm
+ * Last Modified: Monday, 19th October 2026 5:21:34 pm
+ * Author: Akhil Datla
+ * Copyright © Akhil Datla 2023
+ */
+
+package licensing
+
+import (
+	"testing"
+	"time"
+
+	"main/backend/courses"
+)
+
+func TestRegisterEmbargoedCourses(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name          string
+		publishAt     []time.Time
+		wantToken     bool
+		wantEmbargoed int
+	}{
+		{"published", []time.Time{{}, now.Add(-time.Hour)}, true, 0},
+		{"partly embargoed", []time.Time{{}, now.Add(time.Hour)}, true, 1},
+		{"embargoed", []time.Time{now.Add(time.Hour), now.Add(24 * time.Hour)}, false, 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			openTestDatabase(t)
+			key := testBundleLicense(t, 1, "embargo-a", "embargo-b")
+			for i, id := range []string{"embargo-a", "embargo-b"} {
+				if err := courses.SetPublicationWindow(id, test.publishAt[i], time.Time{}); err != nil {
+					t.Fatalf("SetPublicationWindow failed: %v", err)
+				}
+			}
+
+			// Pre-loading courses before their release takes the seat without failing
+			for attempt := 1; attempt <= 2; attempt++ {
+				activation, err := RegisterLicense(key, "HW-1")
+				if err != nil {
+					t.Fatalf("attempt %d: RegisterLicense failed: %v", attempt, err)
+				}
+				if (activation.Token != "") != test.wantToken || len(activation.Embargoed) != test.wantEmbargoed {
+					t.Errorf("attempt %d: activation = %+v, want token %v and %d embargoed", attempt, activation, test.wantToken, test.wantEmbargoed)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+/*
+ * File: lifecycle.go
+ * File Created: Monday, 19th October 2026 11:20:20 am
+ * Last Modified: Monday, 19th October 2026 2:48:58 pm
+ * Author: Akhil Datla
+ * Copyright © Akhil Datla 2023
+ */
+
+package licensing
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"main/backend/dbmanager"
+)
+
+// License statuses.
+const (
+	StatusIssued    = "issued"
+	StatusActivated = "activated"
+	StatusRevoked   = "revoked"
+	StatusExpired   = "expired"
+)
+
+// License event types.
+const (
+	EventIssued             = "issued"
+	EventActivated          = "activated"
+	EventRejected           = "rejected"
+	EventDeactivated        = "deactivated"
+	EventTransferred        = "transferred"
+	EventRenewed            = "renewed"
+	EventSeatsChanged       = "seats changed"
+	EventEntitlementRevoked = "entitlement revoked"
+	EventRevoked            = "revoked"
+	EventBundleExtended     = "bundle extended"
+)
+
+// LicenseEvent represents something that happened to a license.
+type LicenseEvent struct {
+	ID         string    `storm:"id" json:"id"`
+	LicenseID  string    `storm:"index" json:"licenseID"`
+	Type       string    `json:"type"`
+	HardwareID string    `json:"hardwareID"`
+	Detail     string    `json:"detail"`
+	At         time.Time `json:"at"`
+}
+
+// Lifecycle represents everything known about a license, for answering support calls.
+type Lifecycle struct {
+	Key         string         `json:"key"`
+	Status      string         `json:"status"`
+	License     License        `json:"license"`
+	Batch       *LicenseBatch  `json:"batch"`
+	Bundle      *Bundle        `json:"bundle"`
+	Activations []Entitlement  `json:"activations"`
+	Events      []LicenseEvent `json:"events"`
+}
+
+// StatusAt returns the status of the license at the given time. A license whose fixed expiry
+// date passed is expired unless it was revoked.
+func (l License) StatusAt(now time.Time) string {
+	if l.Status == StatusRevoked {
+		return StatusRevoked
+	}
+	if !l.ExpiresAt.IsZero() && !now.Before(l.ExpiresAt) {
+		return StatusExpired
+	}
+	if l.Status == "" {
+		// Licenses from before statuses were kept were only stored until activated
+		return StatusIssued
+	}
+	return l.Status
+}
+
+// recordEvent adds an event to the history of the license. A failure to record is not allowed to
+// fail the operation it describes. Entitlements from before licenses were kept have no license.
+func recordEvent(licenseID, eventType, hardwareID, detail string) {
+	if licenseID == "" {
+		return
+	}
+
+	dbmanager.Save(&LicenseEvent{
+		ID:         uuid.NewV4().String(),
+		LicenseID:  licenseID,
+		Type:       eventType,
+		HardwareID: hardwareID,
+		Detail:     detail,
+		At:         time.Now(),
+	})
+}
+
+// GetLicenseEvents retrieves the history of the license with the given ID, oldest first.
+func GetLicenseEvents(licenseID string) ([]LicenseEvent, error) {
+	var events []LicenseEvent
+	err := dbmanager.GroupQuery("LicenseID", licenseID, &events)
+	if dbmanager.IsNotFound(err) {
+		return make([]LicenseEvent, 0), nil
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].At.Before(events[j].At)
+	})
+
+	return events, err
+}
+
+// GetLifecycle retrieves the status, batch, activations and history of the license with the given key.
+func GetLifecycle(key string) (Lifecycle, error) {
+	license, err := findLicense(key)
+	if err != nil {
+		return Lifecycle{}, err
+	}
+
+	activations, err := GetLicenseActivations(license.ID)
+	if err != nil {
+		return Lifecycle{}, err
+	}
+
+	events, err := GetLicenseEvents(license.ID)
+	if err != nil {
+		return Lifecycle{}, err
+	}
+
+	lifecycle := Lifecycle{
+		Key:         FormatLicenseKey(license.ID, license.Prefix),
+		Status:      license.StatusAt(time.Now()),
+		License:     license,
+		Activations: activations,
+		Events:      events,
+	}
+
+	if license.BatchID != "" {
+		if batch, err := GetBatch(license.BatchID); err == nil {
+			lifecycle.Batch = &batch
+		}
+	}
+
+	if license.BundleID != "" {
+		if bundle, err := GetBundle(license.BundleID); err == nil {
+			lifecycle.Bundle = &bundle
+		}
+	}
+
+	return lifecycle, nil
+}
+
+// revokeLicense marks the license revoked so that it cannot be activated anymore while its
+// history is kept.
+func revokeLicense(license License, detail string) error {
+	if license.Status == StatusRevoked {
+		return errors.New("license already revoked")
+	}
+
+	err := dbmanager.Update(&License{ID: license.ID, Status: StatusRevoked, RevokedAt: time.Now()})
+	if err != nil {
+		return err
+	}
+
+	recordEvent(license.ID, EventRevoked, "", detail)
+	return nil
+}
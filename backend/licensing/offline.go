@@ -0,0 +1,362 @@
+/*
+ * File: offline.go
+ * File Created: Monday, 19th October 2026 10:47:08 am
+ * Last Modified: Monday, 19th October 2026 7:00:19 pm
+ * Author: Akhil Datla
+ * Copyright © Akhil Datla 2023
+ */
+
+package licensing
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"main/backend/dbmanager"
+)
+
+const (
+	// activationVersion is the format version of activation codes. Earlier codes are no longer
+	// accepted.
+	activationVersion = 3
+
+	// activationBodySize is the number of bytes of an activation code before its MAC.
+	activationBodySize = 3
+
+	// activationMACSize is the number of bytes of the MAC kept in an activation code.
+	activationMACSize = 10
+
+	// secondsPerDay is the granularity of expiry dates in activation codes.
+	secondsPerDay = 24 * 60 * 60
+)
+
+// ErrInvalidRequestCode is returned for request codes that are garbled or were not made by RequestCode.
+var ErrInvalidRequestCode = errors.New("invalid request code")
+
+// Request code formats for the license key.
+const (
+	requestUUIDKey   = 1
+	requestBase32Key = 2
+)
+
+const (
+	// symbolsPerBlock base32 symbols pack into bytesPerBlock bytes.
+	symbolsPerBlock = 8
+	bytesPerBlock   = 5
+
+	// maxRequestKeySize is the number of license key symbols a request code can hold.
+	maxRequestKeySize = 255
+)
+
+// OfflineActivation represents the result of activating a license from a request code.
+type OfflineActivation struct {
+	LicenseKey     string   `json:"licenseKey"`
+	HardwareID     string   `json:"hardwareID"`
+	RemainingSeats int      `json:"remainingSeats"`
+	ActivationCode string   `json:"activationCode"`
+	Token          string   `json:"token"`
+	Embargoed      []string `json:"embargoed"`
+}
+
+// RequestCode returns the code a device without connectivity shows to ask for the activation of
+// the license key on its hardware ID.
+func RequestCode(licenseKey, hardwareID string) (string, error) {
+	if licenseKey == "" || hardwareID == "" {
+		return "", errors.New("license key and hardware id required")
+	}
+
+	key, err := ParseLicenseKey(licenseKey)
+	if err != nil {
+		return "", err
+	}
+
+	// Store the license key in as few bytes as its format allows
+	var data []byte
+	switch {
+	case isLegacyKey(key):
+		id, _ := uuid.FromString(key)
+		data = append([]byte{requestUUIDKey}, id.Bytes()...)
+	case len(key) <= maxRequestKeySize:
+		padded := key + strings.Repeat("0", (symbolsPerBlock-len(key)%symbolsPerBlock)%symbolsPerBlock)
+		packed, err := decodeBase32(padded)
+		if err != nil {
+			return "", err
+		}
+		data = append([]byte{requestBase32Key, byte(len(key))}, packed...)
+	default:
+		return "", errors.New("license key too long")
+	}
+
+	return formatCode(append(data, hardwareID...)), nil
+}
+
+// ParseRequestCode returns the license key and hardware ID of a request code.
+func ParseRequestCode(code string) (string, string, error) {
+	data, err := parseCode(code)
+	if err != nil {
+		return "", "", fmt.Errorf("%w: %v", ErrInvalidRequestCode, err)
+	}
+
+	var licenseKey string
+	switch {
+	case len(data) > 17 && data[0] == requestUUIDKey:
+		id, err := uuid.FromBytes(data[1:17])
+		if err != nil {
+			return "", "", ErrInvalidRequestCode
+		}
+		licenseKey, data = id.String(), data[17:]
+	case len(data) > 2 && data[0] == requestBase32Key:
+		size := (int(data[1]) + symbolsPerBlock - 1) / symbolsPerBlock * bytesPerBlock
+		if len(data) <= 2+size {
+			return "", "", ErrInvalidRequestCode
+		}
+		licenseKey, data = encodeBase32(data[2 : 2+size])[:data[1]], data[2+size:]
+	default:
+		return "", "", ErrInvalidRequestCode
+	}
+
+	return licenseKey, string(data), nil
+}
+
+// ActivateOffline registers the license of a request code on its hardware ID for a client at the
+// given IP address, throttled like RegisterLicenseFrom, and returns the activation code to type
+// into the device. The registration is undone if no activation code can be made for it.
+func ActivateOffline(ip, requestCode string) (OfflineActivation, error) {
+	licenseKey, hardwareID, err := ParseRequestCode(requestCode)
+	if err != nil {
+		// Garbled request codes count against the address they came from
+		now := time.Now()
+		if throttleErr := checkThrottle(ip, "", now); throttleErr != nil {
+			return OfflineActivation{}, throttleErr
+		}
+		recordFailure(ip, "", "", err, now)
+		return OfflineActivation{}, err
+	}
+
+	// Remember the entitlements held before so that a failed activation can be undone
+	previous := make(map[string]bool)
+	if existing, err := GetLicenseActivations(licenseKey); err == nil {
+		for _, entitlement := range existing {
+			previous[entitlement.ID] = true
+		}
+	}
+
+	activation, err := RegisterLicenseFrom(ip, licenseKey, hardwareID)
+	if err != nil {
+		return OfflineActivation{}, err
+	}
+
+	license, err := findLicense(licenseKey)
+	if err == nil {
+		var data []byte
+		data, err = offlineActivationCode(license, hardwareID)
+		if err == nil {
+			return OfflineActivation{
+				LicenseKey:     license.ID,
+				HardwareID:     hardwareID,
+				RemainingSeats: activation.RemainingSeats,
+				ActivationCode: formatCode(data),
+				Token:          activation.Token,
+				Embargoed:      activation.Embargoed,
+			}, nil
+		}
+	}
+
+	undoActivation(license.ID, hardwareID, previous)
+	return OfflineActivation{}, err
+}
+
+// undoActivation deletes the entitlements the hardware ID holds through the license that are not
+// among the previous ones.
+func undoActivation(licenseID, hardwareID string, previous map[string]bool) {
+	registerMutex.Lock()
+	defer registerMutex.Unlock()
+
+	activations, err := GetLicenseActivations(licenseID)
+	if err != nil {
+		return
+	}
+	for _, activation := range activations {
+		if activation.HardwareID == hardwareID && !previous[activation.ID] {
+			deactivate(activation)
+		}
+	}
+}
+
+// offlineActivationCode returns the data of the activation code of the license on the hardware ID
+// for its courses, expiring with the entitlements the hardware ID holds through it.
+func offlineActivationCode(license License, hardwareID string) ([]byte, error) {
+	courseIDs, err := license.courseIDs()
+	if err != nil {
+		return nil, err
+	}
+
+	activations, err := GetLicenseActivations(license.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The latest expiry counts, and any perpetual entitlement makes the activation perpetual
+	var expiresAt time.Time
+	held := false
+	for _, entitlement := range activations {
+		if entitlement.HardwareID != hardwareID || entitlement.IsRevoked() {
+			continue
+		}
+		if !held || (!expiresAt.IsZero() && (entitlement.ExpiresAt.IsZero() || entitlement.ExpiresAt.After(expiresAt))) {
+			expiresAt = entitlement.ExpiresAt
+		}
+		held = true
+	}
+	if !held {
+		return nil, errors.New("license not activated on this device")
+	}
+
+	return activationCode(license.ID, hardwareID, courseIDs, expiresAt)
+}
+
+// activationMessage returns the message authenticated by an activation code with the given body:
+// the license key, the hardware ID and the sorted IDs of the courses the code unlocks.
+func activationMessage(licenseKey, hardwareID string, courseIDs []string, body []byte) []byte {
+	sorted := append([]string(nil), courseIDs...)
+	sort.Strings(sorted)
+
+	message := "learnado-activation\x00" + licenseKey + "\x00" + hardwareID + "\x00" + strings.Join(sorted, "\x00") + "\x00"
+	return append([]byte(message), body...)
+}
+
+// activationCode returns the data of the activation code of the license key for the courses on
+// the hardware ID: the body followed by a MAC truncated to activationMACSize bytes, keyed with a
+// secret derived from the server key that never leaves the server. The expiry is rounded up to
+// whole days since the Unix epoch to keep the code short; zero means perpetual.
+func activationCode(licenseKey, hardwareID string, courseIDs []string, expiresAt time.Time) ([]byte, error) {
+	key, err := serverKey()
+	if err != nil {
+		return nil, err
+	}
+
+	days := 0
+	if !expiresAt.IsZero() {
+		days = int((expiresAt.Unix() + secondsPerDay - 1) / secondsPerDay)
+		if days < 1 || days > 0xFFFF {
+			return nil, errors.New("expiry out of range")
+		}
+	}
+
+	body := []byte{activationVersion, 0, 0}
+	binary.BigEndian.PutUint16(body[1:], uint16(days))
+
+	secret := hmac.New(sha256.New, key.Seed())
+	secret.Write([]byte("learnado-activation-key"))
+	mac := hmac.New(sha256.New, secret.Sum(nil))
+	mac.Write(activationMessage(licenseKey, hardwareID, courseIDs, body))
+
+	return append(body, mac.Sum(nil)[:activationMACSize]...), nil
+}
+
+// activationHash returns the hash an activation code is listed under in activation lists.
+func activationHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// ActivationList represents the activation codes issued for the licenses of a device, keyed by
+// license key. Codes are listed as hashes so that the list cannot be used to make a code.
+type ActivationList struct {
+	HardwareID string            `json:"hardwareID"`
+	Codes      map[string]string `json:"codes"`
+	IssuedAt   time.Time         `json:"issuedAt"`
+}
+
+// signActivations returns the signed activation list of the licenses the active entitlements of
+// the hardware ID were activated from.
+func signActivations(hardwareID string, active []Entitlement, issuedAt time.Time) (string, error) {
+	list := ActivationList{
+		HardwareID: hardwareID,
+		Codes:      make(map[string]string),
+		IssuedAt:   issuedAt,
+	}
+
+	for _, entitlement := range active {
+		if entitlement.LicenseID == "" || list.Codes[entitlement.LicenseID] != "" {
+			continue
+		}
+
+		var license License
+		if dbmanager.Query("ID", entitlement.LicenseID, &license) != nil {
+			continue
+		}
+		data, err := offlineActivationCode(license, hardwareID)
+		if err != nil {
+			return "", err
+		}
+		list.Codes[license.ID] = activationHash(data)
+	}
+
+	payload, err := json.Marshal(list)
+	if err != nil {
+		return "", err
+	}
+
+	return sign(payload)
+}
+
+// VerifyActivationCode checks an activation code for the license key on the hardware ID fully
+// offline. The signed activation list comes from learnado-license.json of a package for the device
+// and is checked against the server public key from /licenses/publickey. It returns when the
+// activation expires, or the zero time if it is perpetual.
+func VerifyActivationCode(code, activations string, publicKey ed25519.PublicKey, licenseKey, hardwareID string, now time.Time) (time.Time, error) {
+	licenseKey, err := ParseLicenseKey(licenseKey)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	data, err := parseCode(code)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(data) != activationBodySize+activationMACSize || data[0] != activationVersion {
+		return time.Time{}, errors.New("invalid activation code")
+	}
+
+	payload, err := verify(activations, publicKey)
+	if err != nil {
+		return time.Time{}, err
+	}
+	var list ActivationList
+	if err := json.Unmarshal(payload, &list); err != nil {
+		return time.Time{}, err
+	}
+	if list.HardwareID != hardwareID {
+		return time.Time{}, errors.New("activation list issued to another device")
+	}
+
+	expected, ok := list.Codes[licenseKey]
+	if !ok || subtle.ConstantTimeCompare([]byte(expected), []byte(activationHash(data))) != 1 {
+		return time.Time{}, errors.New("invalid activation code")
+	}
+
+	days := binary.BigEndian.Uint16(data[1:activationBodySize])
+	if days == 0 {
+		return time.Time{}, nil
+	}
+
+	expiresAt := time.Unix(int64(days)*secondsPerDay, 0)
+	if !now.Before(expiresAt) {
+		return expiresAt, errors.New("activation expired")
+	}
+
+	return expiresAt, nil
+}
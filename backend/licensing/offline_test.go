@@ -0,0 +1,298 @@
+/*
+ * File: offline_test.go
+ * File Created: Monday, 19th October 2026 4:26:19 pm
+ * Last Modified: Monday, 19th October 2026 5:32:43 pm
+ * Author: Akhil Datla
+ * Copyright © Akhil Datla 2023
+ */
+
+package licensing
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"main/backend/dbmanager"
+	"main/backend/organizations"
+)
+
+// openTestDatabase opens an empty database for the test, holding the server signing key.
+func openTestDatabase(t *testing.T) {
+	t.Helper()
+
+	if err := dbmanager.Open(filepath.Join(t.TempDir(), "test.db")); err != nil {
+		t.Fatalf("opening database: %v", err)
+	}
+	t.Cleanup(func() {
+		dbmanager.Close()
+	})
+}
+
+// testLicenseKey returns a new license key in the format.
+func testLicenseKey(t *testing.T, format KeyFormat) string {
+	t.Helper()
+
+	key, err := newLicenseKey(format)
+	if err != nil {
+		t.Fatalf("generating license key: %v", err)
+	}
+	return key
+}
+
+func TestRequestCodeRoundTrip(t *testing.T) {
+	tests := []struct {
+		name       string
+		licenseKey string
+		hardwareID string
+	}{
+		{"default format", testLicenseKey(t, DefaultKeyFormat), "HW-1"},
+		{"prefix", testLicenseKey(t, KeyFormat{Prefix: "SCH", Length: 12}), "6f1c2a9e-laptop"},
+		{"longest key", testLicenseKey(t, KeyFormat{Prefix: "ABCDEFGH", Length: maxKeyLength}), "x"},
+		{"legacy key", "7b0e0c1e-5a4d-4b0c-9a57-3c2f1e8d9a60", "classroom-pc-12"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			code, err := RequestCode(FormatLicenseKey(test.licenseKey, ""), test.hardwareID)
+			if err != nil {
+				t.Fatalf("RequestCode failed: %v", err)
+			}
+
+			licenseKey, hardwareID, err := ParseRequestCode(code)
+			if err != nil {
+				t.Fatalf("ParseRequestCode(%q) failed: %v", code, err)
+			}
+			if licenseKey != test.licenseKey || hardwareID != test.hardwareID {
+				t.Errorf("ParseRequestCode(%q) = %q, %q, want %q, %q", code, licenseKey, hardwareID, test.licenseKey, test.hardwareID)
+			}
+		})
+	}
+}
+
+func TestRequestCodeRejectsInvalidKeys(t *testing.T) {
+	key := testLicenseKey(t, DefaultKeyFormat)
+	mistyped := key[:len(key)-1] + string(otherSymbol(key[len(key)-1]))
+
+	tests := []struct {
+		name       string
+		licenseKey string
+		hardwareID string
+	}{
+		{"missing key", "", "HW-1"},
+		{"missing hardware id", key, ""},
+		{"mistyped key", mistyped, "HW-1"},
+		{"not a key", "hello", "HW-1"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := RequestCode(test.licenseKey, test.hardwareID); err == nil {
+				t.Errorf("RequestCode(%q, %q) succeeded, want an error", test.licenseKey, test.hardwareID)
+			}
+		})
+	}
+}
+
+// testActivationList returns an activation list for the hardware ID listing the codes by license
+// key, signed with the given key.
+func testActivationList(t *testing.T, key ed25519.PrivateKey, hardwareID string, codes map[string][]byte) string {
+	t.Helper()
+
+	list := ActivationList{HardwareID: hardwareID, Codes: make(map[string]string)}
+	for licenseKey, data := range codes {
+		list.Codes[licenseKey] = activationHash(data)
+	}
+
+	payload, err := json.Marshal(list)
+	if err != nil {
+		t.Fatalf("encoding activation list: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(ed25519.Sign(key, payload))
+}
+
+func TestActivationCodeRoundTrip(t *testing.T) {
+	openTestDatabase(t)
+
+	key, err := serverKey()
+	if err != nil {
+		t.Fatalf("serverKey failed: %v", err)
+	}
+
+	licenseKey := testLicenseKey(t, DefaultKeyFormat)
+	now := time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC)
+	expiresAt := now.Add(30 * 24 * time.Hour)
+
+	tests := []struct {
+		name      string
+		expiresAt time.Time
+		want      time.Time
+	}{
+		{"perpetual", time.Time{}, time.Time{}},
+		{"midnight", expiresAt.Truncate(24 * time.Hour), expiresAt.Truncate(24 * time.Hour)},
+		{"rounded up to whole days", expiresAt, expiresAt.Truncate(24 * time.Hour).Add(24 * time.Hour)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := activationCode(licenseKey, "HW-1", []string{"course-b", "course-a"}, test.expiresAt)
+			if err != nil {
+				t.Fatalf("activationCode failed: %v", err)
+			}
+			code := formatCode(data)
+			activations := testActivationList(t, key, "HW-1", map[string][]byte{licenseKey: data})
+
+			// Codes stay short enough to type
+			if groups := strings.Count(code, "-") + 1; groups > 5 {
+				t.Errorf("activation code %q has %d groups, want at most 5", code, groups)
+			}
+
+			// The license key is accepted in any typed form
+			got, err := VerifyActivationCode(strings.ToLower(code), activations, key.Public().(ed25519.PublicKey), FormatLicenseKey(licenseKey, ""), "HW-1", now)
+			if err != nil {
+				t.Fatalf("VerifyActivationCode(%q) failed: %v", code, err)
+			}
+			if !got.Equal(test.want) {
+				t.Errorf("VerifyActivationCode(%q) = %v, want %v", code, got, test.want)
+			}
+		})
+	}
+}
+
+func TestVerifyActivationCodeRejects(t *testing.T) {
+	openTestDatabase(t)
+
+	key, err := serverKey()
+	if err != nil {
+		t.Fatalf("serverKey failed: %v", err)
+	}
+	publicKey := key.Public().(ed25519.PublicKey)
+
+	licenseKey := testLicenseKey(t, DefaultKeyFormat)
+	otherKey := testLicenseKey(t, DefaultKeyFormat)
+	now := time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC)
+
+	data, err := activationCode(licenseKey, "HW-1", []string{"course-a", "course-b"}, now.Add(48*time.Hour))
+	if err != nil {
+		t.Fatalf("activationCode failed: %v", err)
+	}
+	code := formatCode(data)
+	activations := testActivationList(t, key, "HW-1", map[string][]byte{licenseKey: data})
+
+	// Flip a bit of the MAC and print the code again with a valid check symbol
+	changed := append([]byte(nil), data...)
+	changed[activationBodySize] ^= 1
+	tampered := formatCode(changed)
+
+	// Change the expiry without authenticating it again
+	changed = append([]byte(nil), data...)
+	changed[2]++
+	extended := formatCode(changed)
+
+	symbols := normalizeCode(code)
+	mistyped := symbols[:3] + string(otherSymbol(symbols[3])) + symbols[4:]
+
+	// A code for fewer courses than the license unlocks
+	fewer, err := activationCode(licenseKey, "HW-1", []string{"course-a"}, now.Add(48*time.Hour))
+	if err != nil {
+		t.Fatalf("activationCode failed: %v", err)
+	}
+
+	_, otherPrivateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	forged := testActivationList(t, otherPrivateKey, "HW-1", map[string][]byte{licenseKey: changed})
+
+	// The list of the device with the signature of another list
+	other := testActivationList(t, key, "HW-1", map[string][]byte{licenseKey: changed})
+	tamperedList := other[:strings.Index(other, ".")] + activations[strings.Index(activations, "."):]
+
+	tests := []struct {
+		name        string
+		code        string
+		activations string
+		publicKey   ed25519.PublicKey
+		licenseKey  string
+		hardwareID  string
+		now         time.Time
+	}{
+		{"tampered MAC", tampered, activations, publicKey, licenseKey, "HW-1", now},
+		{"changed expiry", extended, activations, publicKey, licenseKey, "HW-1", now},
+		{"changed expiry with forged list", extended, forged, publicKey, licenseKey, "HW-1", now},
+		{"mistyped", mistyped, activations, publicKey, licenseKey, "HW-1", now},
+		{"other courses", formatCode(fewer), activations, publicKey, licenseKey, "HW-1", now},
+		{"other server", code, activations, otherPrivateKey.Public().(ed25519.PublicKey), licenseKey, "HW-1", now},
+		{"other license", code, activations, publicKey, otherKey, "HW-1", now},
+		{"other device", code, activations, publicKey, licenseKey, "HW-2", now},
+		{"tampered list", code, tamperedList, publicKey, licenseKey, "HW-1", now},
+		{"missing list", code, "", publicKey, licenseKey, "HW-1", now},
+		{"expired", code, activations, publicKey, licenseKey, "HW-1", now.Add(72 * time.Hour)},
+		{"empty", "", activations, publicKey, licenseKey, "HW-1", now},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := VerifyActivationCode(test.code, test.activations, test.publicKey, test.licenseKey, test.hardwareID, test.now)
+			if err == nil {
+				t.Errorf("VerifyActivationCode(%q) succeeded, want an error", test.code)
+			}
+		})
+	}
+}
+
+func TestActivateOffline(t *testing.T) {
+	openTestDatabase(t)
+
+	publicKey, err := PublicKey()
+	if err != nil {
+		t.Fatalf("PublicKey failed: %v", err)
+	}
+
+	key := testBundleLicense(t, 1, "offline-a", "offline-b")
+	requestCode, err := RequestCode(key, "HW-1")
+	if err != nil {
+		t.Fatalf("RequestCode failed: %v", err)
+	}
+
+	activation, err := ActivateOffline("10.0.0.2", requestCode)
+	if err != nil {
+		t.Fatalf("ActivateOffline failed: %v", err)
+	}
+
+	// The license file of the next package lists the code for the device
+	entitlements, err := GetEntitlements(organizations.Default, "HW-1")
+	if err != nil {
+		t.Fatalf("GetEntitlements failed: %v", err)
+	}
+	activations, err := signActivations("HW-1", entitlements, time.Now())
+	if err != nil {
+		t.Fatalf("signActivations failed: %v", err)
+	}
+
+	expiresAt, err := VerifyActivationCode(activation.ActivationCode, activations, publicKey, key, "HW-1", time.Now())
+	if err != nil || !expiresAt.IsZero() {
+		t.Errorf("VerifyActivationCode(%q) = %v, %v, want a perpetual activation", activation.ActivationCode, expiresAt, err)
+	}
+
+	// The seat is taken, so another device is refused without using a seat
+	requestCode, _ = RequestCode(key, "HW-2")
+	if _, err := ActivateOffline("10.0.0.2", requestCode); err == nil {
+		t.Errorf("ActivateOffline for a second device succeeded, want no seats left")
+	}
+	if _, err := VerifyActivationCode(activation.ActivationCode, activations, publicKey, key, "HW-2", time.Now()); err == nil {
+		t.Errorf("VerifyActivationCode for another device succeeded, want an error")
+	}
+}
+
+// otherSymbol returns a symbol of the key alphabet other than the given one.
+func otherSymbol(symbol byte) byte {
+	if symbol == crockfordAlphabet[0] {
+		return crockfordAlphabet[1]
+	}
+	return crockfordAlphabet[0]
+}
@@ -0,0 +1,243 @@
+/*
+ * File: revocation.go
+ * File Created: Monday, 19th October 2026 10:25:36 am
+ * Last Modified: Monday, 19th October 2026 5:10:12 pm
+ * Author: Akhil Datla
+ * Copyright © Akhil Datla 2023
+ */
+
+package licensing
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"main/backend/dbmanager"
+)
+
+// RevocationList represents the courses a device must delete.
+type RevocationList struct {
+	HardwareID string    `json:"hardwareID"`
+	CourseIDs  []string  `json:"courseIDs"`
+	IssuedAt   time.Time `json:"issuedAt"`
+}
+
+// Revocation records the revocation of a course, a device or both, optionally limited to one
+// license, so that later activations cannot grant what was revoked. Empty criteria match anything.
+type Revocation struct {
+	ID             string `storm:"id"`
+	OrganizationID string `storm:"index"`
+	LicenseID      string `storm:"index"`
+	CourseID       string
+	HardwareID     string
+	RevokedAt      time.Time
+}
+
+// signRevocations returns the signed revocation list of the courses for the hardware ID.
+func signRevocations(hardwareID string, courseIDs []string, issuedAt time.Time) (string, error) {
+	payload, err := json.Marshal(RevocationList{
+		HardwareID: hardwareID,
+		CourseIDs:  courseIDs,
+		IssuedAt:   issuedAt,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return sign(payload)
+}
+
+// VerifyRevocations checks a signed revocation list against the server public key and returns it.
+func VerifyRevocations(message string, publicKey ed25519.PublicKey) (RevocationList, error) {
+	payload, err := verify(message, publicKey)
+	if err != nil {
+		return RevocationList{}, err
+	}
+
+	var list RevocationList
+	err = json.Unmarshal(payload, &list)
+	return list, err
+}
+
+// RevokeEntitlements revokes the activated entitlements of the organization matching every given
+// license ID, course ID and hardware ID, leaving empty criteria out. Revoking by license also marks the license revoked so
+// that it cannot be activated again, and revoking by course or device keeps a revocation record that
+// later activations are checked against. Devices delete revoked courses the next time they download.
+// It returns the number of revoked entitlements.
+func RevokeEntitlements(organizationID, licenseID, courseID, hardwareID string) (int, error) {
+	registerMutex.Lock()
+	defer registerMutex.Unlock()
+
+	var err error
+	if licenseID != "" {
+		licenseID, err = ParseLicenseKey(licenseID)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	// Query by the most selective criterion given
+	var entitlements []Entitlement
+	switch {
+	case licenseID != "":
+		err = dbmanager.OrganizationGroupQuery(organizationID, "LicenseID", licenseID, &entitlements)
+	case hardwareID != "":
+		err = dbmanager.OrganizationGroupQuery(organizationID, "HardwareID", hardwareID, &entitlements)
+	case courseID != "":
+		err = dbmanager.OrganizationGroupQuery(organizationID, "CourseID", courseID, &entitlements)
+	default:
+		return 0, errors.New("license key, course id or hardware id required")
+	}
+	if err != nil && !dbmanager.IsNotFound(err) {
+		return 0, err
+	}
+
+	// Keep revoking the course or device for later activations, even if none was activated yet
+	now := time.Now()
+	if courseID != "" || hardwareID != "" {
+		err = dbmanager.Save(&Revocation{
+			ID:             uuid.NewV4().String(),
+			OrganizationID: organizationID,
+			LicenseID:      licenseID,
+			CourseID:       courseID,
+			HardwareID:     hardwareID,
+			RevokedAt:      now,
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	revoked := 0
+	hardwareIDs := make([]string, 0)
+	for _, entitlement := range entitlements {
+		if entitlement.IsRevoked() ||
+			(courseID != "" && entitlement.CourseID != courseID) ||
+			(hardwareID != "" && entitlement.HardwareID != hardwareID) {
+			continue
+		}
+
+		err = dbmanager.Update(&Entitlement{ID: entitlement.ID, RevokedAt: now})
+		if err != nil {
+			return revoked, err
+		}
+		revoked++
+		recordEvent(entitlement.LicenseID, EventEntitlementRevoked, entitlement.HardwareID, "course "+entitlement.CourseID)
+
+		if !contains(hardwareIDs, entitlement.HardwareID) {
+			hardwareIDs = append(hardwareIDs, entitlement.HardwareID)
+		}
+	}
+
+	// Devices holding revoked courses need to sync
+	for _, id := range hardwareIDs {
+		markDeviceStale(id)
+	}
+
+	if licenseID != "" {
+		var license License
+		if dbmanager.OrganizationQuery(organizationID, "ID", licenseID, &license) != nil {
+			if len(entitlements) == 0 {
+				return 0, ErrInvalidKey
+			}
+			return revoked, nil
+		}
+		if license.Status != StatusRevoked {
+			return revoked, revokeLicense(license, "")
+		}
+	}
+
+	return revoked, nil
+}
+
+// matches reports whether the revocation covers the course of the license on the hardware ID.
+func (r Revocation) matches(licenseID, courseID, hardwareID string) bool {
+	return (r.LicenseID == "" || r.LicenseID == licenseID) &&
+		(r.CourseID == "" || r.CourseID == courseID) &&
+		(r.HardwareID == "" || r.HardwareID == hardwareID)
+}
+
+// getRevocations retrieves the revocation records of the organization with the given ID.
+func getRevocations(organizationID string) ([]Revocation, error) {
+	var revocations []Revocation
+	err := dbmanager.OrganizationQueryAll(organizationID, &revocations)
+	if dbmanager.IsNotFound(err) {
+		return make([]Revocation, 0), nil
+	}
+	return revocations, err
+}
+
+// revokedCourses returns the IDs of the courses of the license that were revoked for the hardware ID,
+// either through revocation records or through revoked entitlements of the device.
+func revokedCourses(license License, hardwareID string, activations []Entitlement) ([]string, error) {
+	courseIDs, err := license.courseIDs()
+	if err != nil {
+		return nil, err
+	}
+
+	revocations, err := getRevocations(license.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	revoked := make([]string, 0)
+	for _, courseID := range courseIDs {
+		for _, revocation := range revocations {
+			if revocation.matches(license.ID, courseID, hardwareID) {
+				revoked = append(revoked, courseID)
+				break
+			}
+		}
+	}
+
+	for _, activation := range activations {
+		if activation.HardwareID == hardwareID && activation.IsRevoked() && !contains(revoked, activation.CourseID) {
+			revoked = append(revoked, activation.CourseID)
+		}
+	}
+
+	return revoked, nil
+}
+
+// revokedFor reports whether the course of the entitlement was revoked for the hardware ID.
+func revokedFor(entitlement Entitlement, hardwareID string) (bool, error) {
+	revocations, err := getRevocations(entitlement.OrganizationID)
+	if err != nil {
+		return false, err
+	}
+
+	for _, revocation := range revocations {
+		if revocation.matches(entitlement.LicenseID, entitlement.CourseID, hardwareID) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// GetRevokedEntitlements retrieves every revoked entitlement of the organization, most recently
+// revoked first.
+func GetRevokedEntitlements(organizationID string) ([]Entitlement, error) {
+	var entitlements []Entitlement
+	err := dbmanager.OrganizationQueryAll(organizationID, &entitlements)
+	if dbmanager.IsNotFound(err) {
+		err = nil
+	}
+
+	revoked := make([]Entitlement, 0)
+	for _, entitlement := range entitlements {
+		if entitlement.IsRevoked() {
+			revoked = append(revoked, entitlement)
+		}
+	}
+
+	sort.Slice(revoked, func(i, j int) bool {
+		return revoked[i].RevokedAt.After(revoked[j].RevokedAt)
+	})
+
+	return revoked, err
+}
@@ -0,0 +1,148 @@
+/*
+ * File: revocation_test.go
+ * File Created: Monday, 19th October 2026 4:59:22 pm
+ * Last Modified: Monday, 19th October 2026 5:10:12 pm
+ * Author: Akhil Datla
+ * Copyright © Akhil Datla 2023
+ */
+
+package licensing
+
+import (
+	"sort"
+	"testing"
+
+	"main/backend/courses"
+	"main/backend/dbmanager"
+	"main/backend/organizations"
+)
+
+// testBundleLicense saves published courses with the given IDs and returns the key of a license
+// with the given number of seats for a bundle of them.
+func testBundleLicense(t *testing.T, seats int, courseIDs ...string) string {
+	t.Helper()
+
+	for _, id := range courseIDs {
+		err := dbmanager.Save(&courses.Course{ID: id, Name: id, OrganizationID: organizations.Default})
+		if err != nil {
+			t.Fatalf("saving course: %v", err)
+		}
+	}
+
+	bundleID, err := CreateBundle(organizations.Default, "bundle of "+courseIDs[0], courseIDs, false)
+	if err != nil {
+		t.Fatalf("CreateBundle failed: %v", err)
+	}
+
+	_, keys, err := GenerateBatch(LicenseBatch{BundleID: bundleID, OrganizationID: organizations.Default}, 1, Terms{Seats: seats})
+	if err != nil {
+		t.Fatalf("GenerateBatch failed: %v", err)
+	}
+	return keys[0]
+}
+
+// activeCourses returns the sorted courses the hardware ID holds unrevoked entitlements to through
+// the license.
+func activeCourses(t *testing.T, key, hardwareID string) []string {
+	t.Helper()
+
+	activations, err := GetLicenseActivations(key)
+	if err != nil {
+		t.Fatalf("GetLicenseActivations failed: %v", err)
+	}
+
+	courseIDs := make([]string, 0)
+	for _, activation := range activations {
+		if activation.HardwareID == hardwareID && !activation.IsRevoked() {
+			courseIDs = append(courseIDs, activation.CourseID)
+		}
+	}
+	sort.Strings(courseIDs)
+	return courseIDs
+}
+
+func TestDeactivateKeepsRevocations(t *testing.T) {
+	tests := []struct {
+		name       string
+		courseID   string
+		hardwareID string
+		wantErr    bool
+		want       []string
+	}{
+		{"revoked course", "deactivate-a", "", false, []string{"deactivate-b"}},
+		{"revoked device", "", "HW-1", true, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			openTestDatabase(t)
+			key := testBundleLicense(t, 1, "deactivate-a", "deactivate-b")
+
+			if _, err := RegisterLicense(key, "HW-1"); err != nil {
+				t.Fatalf("RegisterLicense failed: %v", err)
+			}
+			if _, err := RevokeEntitlements(organizations.Default, "", test.courseID, test.hardwareID); err != nil {
+				t.Fatalf("RevokeEntitlements failed: %v", err)
+			}
+
+			// Deactivating returns the seat but keeps what was revoked
+			err := DeactivateDevice(key, "HW-1")
+			if (err != nil) != test.wantErr {
+				t.Fatalf("DeactivateDevice = %v, want error %v", err, test.wantErr)
+			}
+
+			_, err = RegisterLicense(key, "HW-1")
+			if (err != nil) != test.wantErr {
+				t.Fatalf("RegisterLicense after deactivation = %v, want error %v", err, test.wantErr)
+			}
+			if got := activeCourses(t, key, "HW-1"); !test.wantErr && (len(got) != len(test.want) || got[0] != test.want[0]) {
+				t.Errorf("courses after registering again = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRevocationsOutlastEntitlements(t *testing.T) {
+	tests := []struct {
+		name       string
+		courseID   string
+		hardwareID string
+		register   string
+		wantErr    bool
+		want       []string
+	}{
+		{"revoked course on a new device", "outlast-a", "", "HW-2", false, []string{"outlast-b"}},
+		{"revoked course and device", "outlast-a", "HW-2", "HW-2", false, []string{"outlast-b"}},
+		{"revoked device before activation", "", "HW-2", "HW-2", true, nil},
+		{"other device", "", "HW-3", "HW-2", false, []string{"outlast-a", "outlast-b"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			openTestDatabase(t)
+			key := testBundleLicense(t, 2, "outlast-a", "outlast-b")
+
+			if _, err := RegisterLicense(key, "HW-1"); err != nil {
+				t.Fatalf("RegisterLicense failed: %v", err)
+			}
+			if _, err := RevokeEntitlements(organizations.Default, "", test.courseID, test.hardwareID); err != nil {
+				t.Fatalf("RevokeEntitlements failed: %v", err)
+			}
+
+			_, err := RegisterLicense(key, test.register)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("RegisterLicense = %v, want error %v", err, test.wantErr)
+			}
+
+			got := activeCourses(t, key, test.register)
+			if len(got) != len(test.want) {
+				t.Fatalf("courses of %s = %v, want %v", test.register, got, test.want)
+			}
+			for i := range got {
+				if got[i] != test.want[i] {
+					t.Errorf("courses of %s = %v, want %v", test.register, got, test.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+/*
+ * File: seats.go
+ * File Created: Monday, 19th October 2026 10:03:23 am
+ * Last Modified: Monday, 19th October 2026 2:59:05 pm
+ * Author: Akhil Datla
+ * Copyright © Akhil Datla 2023
+ */
+
+package licensing
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+
+	"main/backend/dbmanager"
+)
+
+// Seats represents the seat usage of a license.
+type Seats struct {
+	LicenseID   string        `json:"licenseID"`
+	Seats       int           `json:"seats"`
+	Remaining   int           `json:"remaining"`
+	Activations []Entitlement `json:"activations"`
+}
+
+// seats returns the number of seats of the license, treating licenses from before seats were
+// introduced as single-seat licenses.
+func (l License) seats() int {
+	if l.Seats < 1 {
+		return 1
+	}
+	return l.Seats
+}
+
+// GetLicenseActivations retrieves the entitlements created from the license with the given ID,
+// oldest first. Each hardware ID holding entitlements takes one seat.
+func GetLicenseActivations(licenseID string) ([]Entitlement, error) {
+	licenseID, err := ParseLicenseKey(licenseID)
+	if err != nil {
+		return nil, err
+	}
+
+	var entitlements []Entitlement
+	err = dbmanager.GroupQuery("LicenseID", licenseID, &entitlements)
+	if dbmanager.IsNotFound(err) {
+		// An unactivated license has no entitlements
+		entitlements = make([]Entitlement, 0)
+		err = nil
+	}
+
+	sort.Slice(entitlements, func(i, j int) bool {
+		return entitlements[i].ActivatedAt.Before(entitlements[j].ActivatedAt)
+	})
+
+	return entitlements, err
+}
+
+// hardwareIDs returns the hardware IDs holding the entitlements, in order of first appearance.
+// A hardware ID takes one seat however many courses of the license it is entitled to.
+func hardwareIDs(entitlements []Entitlement) []string {
+	ids := make([]string, 0, len(entitlements))
+	for _, entitlement := range entitlements {
+		if !contains(ids, entitlement.HardwareID) {
+			ids = append(ids, entitlement.HardwareID)
+		}
+	}
+	return ids
+}
+
+// seatHolders returns the hardware IDs taking a seat through the entitlements. Revoked
+// entitlements take no seat.
+func seatHolders(entitlements []Entitlement) []string {
+	held := make([]Entitlement, 0, len(entitlements))
+	for _, entitlement := range entitlements {
+		if !entitlement.IsRevoked() {
+			held = append(held, entitlement)
+		}
+	}
+	return hardwareIDs(held)
+}
+
+// GetLicenseSeats retrieves the seat usage of the license with the given ID.
+func GetLicenseSeats(licenseID string) (Seats, error) {
+	license, err := findLicense(licenseID)
+	if err != nil {
+		return Seats{}, err
+	}
+
+	activations, err := GetLicenseActivations(license.ID)
+	if err != nil {
+		return Seats{}, err
+	}
+
+	return Seats{
+		LicenseID:   license.ID,
+		Seats:       license.seats(),
+		Remaining:   license.seats() - len(seatHolders(activations)),
+		Activations: activations,
+	}, nil
+}
+
+// SetLicenseSeats raises or lowers the number of seats of the license with the given ID.
+// The number of seats cannot be lowered below the seats already taken.
+func SetLicenseSeats(licenseID string, seats int) error {
+	registerMutex.Lock()
+	defer registerMutex.Unlock()
+
+	license, err := findLicense(licenseID)
+	if err != nil {
+		return err
+	}
+
+	if seats < 1 {
+		return errors.New("invalid number of seats")
+	}
+
+	activations, err := GetLicenseActivations(license.ID)
+	if err != nil {
+		return err
+	}
+	if seats < len(seatHolders(activations)) {
+		return errors.New("seats already in use")
+	}
+
+	err = dbmanager.Update(&License{ID: license.ID, Seats: seats})
+	if err != nil {
+		return err
+	}
+
+	recordEvent(license.ID, EventSeatsChanged, "", strconv.Itoa(license.seats())+" to "+strconv.Itoa(seats))
+	return nil
+}
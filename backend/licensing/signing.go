@@ -0,0 +1,111 @@
+/*
+ * File: signing.go
+ * File Created: Monday, 19th October 2026 10:25:36 am
+ * Last Modified: Monday, 19th October 2026 10:25:36 am
+ * Author: Akhil Datla
+ * Copyright © Akhil Datla 2023
+ */
+
+package licensing
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"sync"
+
+	"main/backend/dbmanager"
+)
+
+// signingKeyID is the ID under which the server signing key is stored.
+const signingKeyID = "server"
+
+// SigningKey represents the Ed25519 key the server signs messages for devices with.
+type SigningKey struct {
+	ID         string `storm:"id"`
+	PrivateKey []byte
+}
+
+var (
+	signingMutex sync.Mutex
+	privateKey   ed25519.PrivateKey
+)
+
+// serverKey returns the server signing key, generating and storing it on first use.
+func serverKey() (ed25519.PrivateKey, error) {
+	signingMutex.Lock()
+	defer signingMutex.Unlock()
+
+	if privateKey != nil {
+		return privateKey, nil
+	}
+
+	var key SigningKey
+	err := dbmanager.Query("ID", signingKeyID, &key)
+	if err == nil && len(key.PrivateKey) == ed25519.PrivateKeySize {
+		privateKey = ed25519.PrivateKey(key.PrivateKey)
+		return privateKey, nil
+	}
+
+	// Generate a new key
+	_, generated, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	err = dbmanager.Save(&SigningKey{ID: signingKeyID, PrivateKey: generated})
+	if err != nil {
+		return nil, err
+	}
+
+	privateKey = generated
+	return privateKey, nil
+}
+
+// PublicKey returns the public key devices verify signed messages from the server with.
+func PublicKey() (ed25519.PublicKey, error) {
+	key, err := serverKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return key.Public().(ed25519.PublicKey), nil
+}
+
+// sign signs the payload with the server key and returns it as a compact message: the payload and
+// the signature, each encoded in unpadded base64url and joined by a dot.
+func sign(payload []byte) (string, error) {
+	key, err := serverKey()
+	if err != nil {
+		return "", err
+	}
+
+	signature := ed25519.Sign(key, payload)
+
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// verify checks the signature of a compact message against the public key and returns its payload.
+func verify(message string, publicKey ed25519.PublicKey) ([]byte, error) {
+	parts := strings.Split(message, ".")
+	if len(parts) != 2 {
+		return nil, errors.New("malformed message")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, errors.New("malformed message")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, errors.New("malformed message")
+	}
+
+	if len(publicKey) != ed25519.PublicKeySize || !ed25519.Verify(publicKey, payload, signature) {
+		return nil, errors.New("invalid signature")
+	}
+
+	return payload, nil
+}
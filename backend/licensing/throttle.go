@@ -0,0 +1,377 @@
+/*
+ * File: throttle.go
+ * File Created: Monday, 19th October 2026 11:52:00 am
+ * Last Modified: Monday, 19th October 2026 7:00:19 pm
+ * Author: Akhil Datla
+ * Copyright © Akhil Datla 2023
+ */
+
+package licensing
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"main/backend/dbmanager"
+	"main/backend/organizations"
+)
+
+// RegistrationPolicy limits the failed license registrations of a client IP address or a hardware ID.
+// Only failures that look like guessing count: invalid license keys and garbled request codes.
+// After FreeFailures of them every attempt has to wait BaseDelay, doubled with every further
+// failure up to MaxDelay. LockoutAfter invalid license keys lock the client out for LockoutDuration.
+// Failures are forgotten after ResetAfter without any.
+type RegistrationPolicy struct {
+	FreeFailures    int
+	BaseDelay       time.Duration
+	MaxDelay        time.Duration
+	LockoutAfter    int
+	LockoutDuration time.Duration
+	ResetAfter      time.Duration
+}
+
+// IPPolicy limits the failed registrations of a client IP address. It is more lenient than
+// HardwarePolicy because a whole school may register through one address.
+var IPPolicy = RegistrationPolicy{
+	FreeFailures:    10,
+	BaseDelay:       time.Second,
+	MaxDelay:        5 * time.Minute,
+	LockoutAfter:    50,
+	LockoutDuration: time.Hour,
+	ResetAfter:      time.Hour,
+}
+
+// HardwarePolicy limits the failed registrations of a hardware ID.
+var HardwarePolicy = RegistrationPolicy{
+	FreeFailures:    3,
+	BaseDelay:       2 * time.Second,
+	MaxDelay:        10 * time.Minute,
+	LockoutAfter:    10,
+	LockoutDuration: time.Hour,
+	ResetAfter:      time.Hour,
+}
+
+// maxTrackedClients is the number of clients tracked before forgotten ones are dropped.
+const maxTrackedClients = 100000
+
+// Limits of the log of failed registrations. The failures of an IP address within failureWindow
+// are logged as one entry, entries are kept for failureRetention, and at most maxFailureEntries
+// entries are kept at all, dropping the oldest first.
+const (
+	failureWindow     = time.Hour
+	failureRetention  = 30 * 24 * time.Hour
+	maxFailureEntries = 10000
+)
+
+// FailurePruneInterval is how often PruneFailures drops expired log entries and forgotten clients.
+const FailurePruneInterval = time.Minute
+
+// ThrottleError is returned for registrations refused because the client failed too often.
+type ThrottleError struct {
+	RetryAfter time.Duration
+	Locked     bool
+}
+
+// Error implements the error interface.
+func (e *ThrottleError) Error() string {
+	if e.Locked {
+		return fmt.Sprintf("too many invalid license keys, locked for %s", e.RetryAfter.Round(time.Second))
+	}
+	return fmt.Sprintf("too many failed attempts, retry in %s", e.RetryAfter.Round(time.Second))
+}
+
+// RegistrationFailure represents the failed license registrations of an IP address within an hour,
+// kept for admins to spot guessing. It holds the hardware ID, license key and reason of the latest
+// failure. Failures with a license key that belongs to no license belong to the default organization.
+type RegistrationFailure struct {
+	ID             string    `storm:"id" json:"id"`
+	OrganizationID string    `storm:"index" json:"organizationID"`
+	IP             string    `storm:"index" json:"ip"`
+	HardwareID     string    `json:"hardwareID"`
+	LicenseKey     string    `json:"licenseKey"`
+	Reason         string    `json:"reason"`
+	Count          int       `json:"count"`
+	Locked         bool      `json:"locked"`
+	FirstAt        time.Time `json:"firstAt"`
+	At             time.Time `json:"at"`
+}
+
+// failures represents the recent failed registrations of a client.
+type failures struct {
+	count       int
+	invalidKeys int
+	last        time.Time
+	lockedUntil time.Time
+}
+
+var (
+	throttleMutex sync.Mutex
+	throttled     = make(map[string]*failures)
+)
+
+var (
+	failureLogMutex sync.Mutex
+	openFailures    = make(map[string]RegistrationFailure)
+)
+
+// wait returns how long the client has to wait before its next attempt at the given time, and
+// whether it is locked out.
+func (f *failures) wait(policy RegistrationPolicy, now time.Time) (time.Duration, bool) {
+	if now.Before(f.lockedUntil) {
+		return f.lockedUntil.Sub(now), true
+	}
+	if f.count < policy.FreeFailures || now.Sub(f.last) >= policy.ResetAfter {
+		return 0, false
+	}
+
+	delay := policy.BaseDelay
+	for i := policy.FreeFailures; i < f.count && delay < policy.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > policy.MaxDelay {
+		delay = policy.MaxDelay
+	}
+
+	if retryAt := f.last.Add(delay); now.Before(retryAt) {
+		return retryAt.Sub(now), false
+	}
+	return 0, false
+}
+
+// fail records a failure of the client at the given time and reports whether it locked the client out.
+func (f *failures) fail(policy RegistrationPolicy, invalidKey bool, now time.Time) bool {
+	if now.Sub(f.last) >= policy.ResetAfter {
+		*f = failures{}
+	}
+
+	f.count++
+	f.last = now
+	if invalidKey {
+		f.invalidKeys++
+	}
+
+	if f.invalidKeys >= policy.LockoutAfter {
+		f.lockedUntil = now.Add(policy.LockoutDuration)
+		f.invalidKeys = 0
+		return true
+	}
+	return false
+}
+
+// throttleKeys returns the keys the IP address and the hardware ID are tracked under with their policies.
+func throttleKeys(ip, hardwareID string) ([]string, []RegistrationPolicy) {
+	keys := []string{"ip:" + ip}
+	policies := []RegistrationPolicy{IPPolicy}
+	if hardwareID != "" {
+		keys = append(keys, "hw:"+hardwareID)
+		policies = append(policies, HardwarePolicy)
+	}
+	return keys, policies
+}
+
+// checkThrottle refuses an attempt of the IP address and the hardware ID while either has to wait.
+func checkThrottle(ip, hardwareID string, now time.Time) error {
+	throttleMutex.Lock()
+	defer throttleMutex.Unlock()
+
+	keys, policies := throttleKeys(ip, hardwareID)
+	var refused *ThrottleError
+	for i, key := range keys {
+		f, ok := throttled[key]
+		if !ok {
+			continue
+		}
+
+		wait, locked := f.wait(policies[i], now)
+		if wait > 0 && (refused == nil || wait > refused.RetryAfter) {
+			refused = &ThrottleError{RetryAfter: wait, Locked: locked}
+		}
+	}
+
+	if refused != nil {
+		return refused
+	}
+	return nil
+}
+
+// recordFailure logs a failed attempt of the IP address and the hardware ID. Invalid license keys
+// and garbled request codes also count against both, while other failures, such as a license
+// without free seats, are only logged.
+func recordFailure(ip, licenseKey, hardwareID string, cause error, now time.Time) {
+	invalidKey := errors.Is(cause, ErrInvalidKey) || errors.Is(cause, ErrMistypedKey)
+
+	locked := false
+	if invalidKey || errors.Is(cause, ErrInvalidRequestCode) {
+		throttleMutex.Lock()
+		if len(throttled) >= maxTrackedClients {
+			pruneThrottled(now)
+		}
+		keys, policies := throttleKeys(ip, hardwareID)
+		for i, key := range keys {
+			f, ok := throttled[key]
+			if !ok {
+				f = &failures{}
+				throttled[key] = f
+			}
+			if f.fail(policies[i], invalidKey, now) {
+				locked = true
+			}
+		}
+		throttleMutex.Unlock()
+	}
+
+	logFailure(ip, licenseKey, hardwareID, cause, invalidKey, locked, now)
+}
+
+// logFailure adds a failure to the log entry of the IP address for the organization of the
+// license key, starting a new entry once the current one is older than failureWindow.
+func logFailure(ip, licenseKey, hardwareID string, cause error, invalidKey, locked bool, now time.Time) {
+	// Keep typed keys short enough that the log cannot be flooded with large values
+	if len(licenseKey) > 64 {
+		licenseKey = licenseKey[:64]
+	}
+
+	// Keys that belong to no license need no lookup
+	organizationID := organizations.Default
+	if !invalidKey {
+		if license, err := findLicense(licenseKey); err == nil {
+			organizationID = license.OrganizationID
+		}
+	}
+
+	failureLogMutex.Lock()
+	defer failureLogMutex.Unlock()
+
+	key := ip + "\x00" + organizationID
+	failure, ok := openFailures[key]
+	if !ok || now.Sub(failure.FirstAt) >= failureWindow {
+		failure = RegistrationFailure{
+			ID:             uuid.NewV4().String(),
+			OrganizationID: organizationID,
+			IP:             ip,
+			FirstAt:        now,
+		}
+	}
+
+	failure.HardwareID = hardwareID
+	failure.LicenseKey = licenseKey
+	failure.Reason = cause.Error()
+	failure.Count++
+	failure.Locked = failure.Locked || locked
+	failure.At = now
+
+	if dbmanager.Save(&failure) == nil {
+		openFailures[key] = failure
+	}
+}
+
+// PruneFailures prunes the log of failed registrations and the tracked clients at the given
+// interval until stop is closed, keeping the work out of the registration path.
+func PruneFailures(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			pruneFailures(now)
+
+			throttleMutex.Lock()
+			pruneThrottled(now)
+			throttleMutex.Unlock()
+		}
+	}
+}
+
+// pruneFailures deletes the log entries older than failureRetention and the oldest entries beyond
+// maxFailureEntries, and forgets the entries that no longer take failures.
+func pruneFailures(now time.Time) {
+	failureLogMutex.Lock()
+	for key, failure := range openFailures {
+		if now.Sub(failure.FirstAt) >= failureWindow {
+			delete(openFailures, key)
+		}
+	}
+	failureLogMutex.Unlock()
+
+	var failures []RegistrationFailure
+	dbmanager.QueryAll(&failures)
+	sort.Slice(failures, func(i, j int) bool {
+		return failures[i].At.After(failures[j].At)
+	})
+
+	for i := range failures {
+		if i >= maxFailureEntries || now.Sub(failures[i].At) >= failureRetention {
+			dbmanager.Delete(&failures[i])
+		}
+	}
+}
+
+// pruneThrottled drops the clients whose failures are forgotten and who are not locked out.
+func pruneThrottled(now time.Time) {
+	for key, f := range throttled {
+		if now.Sub(f.last) >= IPPolicy.ResetAfter && now.Sub(f.last) >= HardwarePolicy.ResetAfter && !now.Before(f.lockedUntil) {
+			delete(throttled, key)
+		}
+	}
+}
+
+// RegisterLicenseFrom registers a license like RegisterLicense for a client at the given IP
+// address. Clients and hardware IDs that tried invalid license keys repeatedly have to wait
+// increasingly long between attempts and are locked out after more of them. Every failure is logged.
+func RegisterLicenseFrom(ip, licenseID, hardwareID string) (Activation, error) {
+	now := time.Now()
+	if err := checkThrottle(ip, hardwareID, now); err != nil {
+		return Activation{}, err
+	}
+
+	activation, err := RegisterLicense(licenseID, hardwareID)
+	if err != nil {
+		recordFailure(ip, licenseID, hardwareID, err, now)
+		return Activation{}, err
+	}
+
+	// A registered device is no longer suspicious, unlike an address other devices may share
+	throttleMutex.Lock()
+	delete(throttled, "hw:"+hardwareID)
+	throttleMutex.Unlock()
+
+	return activation, nil
+}
+
+// GetRegistrationFailures retrieves the failed license registrations of the organization with the
+// given ID, most recent first.
+func GetRegistrationFailures(organizationID string) ([]RegistrationFailure, error) {
+	var failures []RegistrationFailure
+	err := dbmanager.OrganizationQueryAll(organizationID, &failures)
+	if dbmanager.IsNotFound(err) {
+		return make([]RegistrationFailure, 0), nil
+	}
+
+	sort.Slice(failures, func(i, j int) bool {
+		return failures[i].At.After(failures[j].At)
+	})
+
+	return failures, err
+}
+
+// Unlock forgets the failed registrations of the IP address and the hardware ID, lifting any
+// lockout. Empty values are left out.
+func Unlock(ip, hardwareID string) {
+	throttleMutex.Lock()
+	defer throttleMutex.Unlock()
+
+	if ip != "" {
+		delete(throttled, "ip:"+ip)
+	}
+	if hardwareID != "" {
+		delete(throttled, "hw:"+hardwareID)
+	}
+}
@@ -0,0 +1,214 @@
+/*
+ * File: throttle_test.go
+ * File Created: Monday, 19th October 2026 4:48:03 pm
+ * Last Modified: Monday, 19th October 2026 7:00:19 pm
+ * Author: Akhil Datla
+ * Copyright © Akhil Datla 2023
+ */
+
+package licensing
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"main/backend/dbmanager"
+	"main/backend/organizations"
+)
+
+var testPolicy = RegistrationPolicy{
+	FreeFailures:    2,
+	BaseDelay:       time.Second,
+	MaxDelay:        4 * time.Second,
+	LockoutAfter:    3,
+	LockoutDuration: time.Hour,
+	ResetAfter:      10 * time.Minute,
+}
+
+func TestFailuresBackoff(t *testing.T) {
+	start := time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		failures int
+		elapsed  time.Duration
+		want     time.Duration
+	}{
+		{"no failures", 0, 0, 0},
+		{"free failure", 1, 0, 0},
+		{"first delay", 2, 0, time.Second},
+		{"delay partly waited", 2, 400 * time.Millisecond, 600 * time.Millisecond},
+		{"delay waited", 2, time.Second, 0},
+		{"doubled delay", 3, 0, 2 * time.Second},
+		{"maximum delay", 4, 0, 4 * time.Second},
+		{"capped delay", 8, 0, 4 * time.Second},
+		{"forgotten failures", 8, 10 * time.Minute, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := &failures{}
+			for i := 0; i < test.failures; i++ {
+				if f.fail(testPolicy, false, start) {
+					t.Fatalf("failure %d without an invalid key locked the client out", i+1)
+				}
+			}
+
+			wait, locked := f.wait(testPolicy, start.Add(test.elapsed))
+			if wait != test.want || locked {
+				t.Errorf("wait = %v, %v, want %v, false", wait, locked, test.want)
+			}
+		})
+	}
+}
+
+func TestFailuresLockout(t *testing.T) {
+	start := time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		invalidKeys []bool
+		lockedOut   bool
+		elapsed     time.Duration
+		wantLocked  bool
+		wantWait    time.Duration
+	}{
+		{"invalid keys", []bool{true, true, true}, true, 0, true, time.Hour},
+		{"lockout partly waited", []bool{true, true, true}, true, 20 * time.Minute, true, 40 * time.Minute},
+		{"lockout waited", []bool{true, true, true}, true, time.Hour, false, 0},
+		{"too few invalid keys", []bool{true, false, true}, false, 0, false, 2 * time.Second},
+		{"garbled request codes", []bool{false, false, false, false}, false, 0, false, 4 * time.Second},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := &failures{}
+			locked := false
+			for _, invalidKey := range test.invalidKeys {
+				locked = f.fail(testPolicy, invalidKey, start)
+			}
+			if locked != test.lockedOut {
+				t.Errorf("fail locked = %v, want %v", locked, test.lockedOut)
+			}
+
+			wait, locked := f.wait(testPolicy, start.Add(test.elapsed))
+			if wait != test.wantWait || locked != test.wantLocked {
+				t.Errorf("wait = %v, %v, want %v, %v", wait, locked, test.wantWait, test.wantLocked)
+			}
+		})
+	}
+}
+
+func TestRecordFailureCountsGuesses(t *testing.T) {
+	openTestDatabase(t)
+
+	savedIP, savedHardware := IPPolicy, HardwarePolicy
+	t.Cleanup(func() {
+		IPPolicy, HardwarePolicy = savedIP, savedHardware
+	})
+	IPPolicy = RegistrationPolicy{BaseDelay: time.Minute, MaxDelay: time.Minute, LockoutAfter: 100, ResetAfter: time.Hour}
+	HardwarePolicy = IPPolicy
+
+	now := time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name          string
+		cause         error
+		wantThrottled bool
+	}{
+		{"invalid key", ErrInvalidKey, true},
+		{"mistyped key", fmt.Errorf("typed key: %w", ErrMistypedKey), true},
+		{"garbled request code", ErrInvalidRequestCode, true},
+		{"no seats left", errors.New("no seats left"), false},
+		{"license expired", errors.New("license expired"), false},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ip, hardwareID := fmt.Sprintf("10.0.1.%d", i), fmt.Sprintf("HW-%d", i)
+			t.Cleanup(func() { Unlock(ip, hardwareID) })
+
+			recordFailure(ip, "", hardwareID, test.cause, now)
+
+			err := checkThrottle(ip, hardwareID, now)
+			if (err != nil) != test.wantThrottled {
+				t.Errorf("checkThrottle after %v = %v, want throttled %v", test.cause, err, test.wantThrottled)
+			}
+		})
+	}
+
+	// Every failure is logged, whether it counts or not
+	logged, err := GetRegistrationFailures(organizations.Default)
+	if err != nil || len(logged) != len(tests) {
+		t.Errorf("logged failures = %d, %v, want %d", len(logged), err, len(tests))
+	}
+}
+
+func TestPruneFailures(t *testing.T) {
+	openTestDatabase(t)
+
+	now := time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC)
+	for i, at := range []time.Time{now, now.Add(-time.Hour), now.Add(-failureRetention)} {
+		failure := RegistrationFailure{ID: fmt.Sprint(i), IP: "10.0.2.1", FirstAt: at, At: at}
+		if err := dbmanager.Save(&failure); err != nil {
+			t.Fatalf("saving failure: %v", err)
+		}
+	}
+
+	pruneFailures(now)
+
+	logged, err := GetRegistrationFailures(organizations.Default)
+	if err != nil || len(logged) != 2 || logged[1].ID != "1" {
+		t.Errorf("failures after pruning = %+v, %v, want the two recent ones", logged, err)
+	}
+}
+
+func TestRegisterLicenseFromLocksOut(t *testing.T) {
+	openTestDatabase(t)
+
+	savedIP, savedHardware := IPPolicy, HardwarePolicy
+	t.Cleanup(func() {
+		IPPolicy, HardwarePolicy = savedIP, savedHardware
+		Unlock("10.0.0.1", "HW-1")
+	})
+
+	// Log entries of earlier tests belong to their own databases
+	failureLogMutex.Lock()
+	openFailures = make(map[string]RegistrationFailure)
+	failureLogMutex.Unlock()
+
+	IPPolicy = RegistrationPolicy{FreeFailures: 100, LockoutAfter: 100, ResetAfter: time.Hour}
+	HardwarePolicy = RegistrationPolicy{LockoutAfter: 3, LockoutDuration: time.Hour, ResetAfter: time.Hour}
+
+	// Keys that do not belong to any license are refused until the hardware ID is locked out
+	unknownKey := testLicenseKey(t, DefaultKeyFormat)
+	for i := 0; i < 3; i++ {
+		_, err := RegisterLicenseFrom("10.0.0.1", unknownKey, "HW-1")
+		if !errors.Is(err, ErrInvalidKey) {
+			t.Fatalf("attempt %d = %v, want %v", i+1, err, ErrInvalidKey)
+		}
+	}
+
+	var throttleErr *ThrottleError
+	_, err := RegisterLicenseFrom("10.0.0.1", unknownKey, "HW-1")
+	if !errors.As(err, &throttleErr) || !throttleErr.Locked {
+		t.Fatalf("attempt after lockout = %v, want a lockout", err)
+	}
+
+	// The refused attempts are logged as one entry of the address
+	logged, err := GetRegistrationFailures(organizations.Default)
+	if err != nil {
+		t.Fatalf("GetRegistrationFailures failed: %v", err)
+	}
+	if len(logged) != 1 || logged[0].IP != "10.0.0.1" || logged[0].Count != 3 || !logged[0].Locked {
+		t.Errorf("logged failures = %+v, want one locked entry of 10.0.0.1 with 3 failures", logged)
+	}
+
+	// Unlocking lifts the lockout
+	Unlock("", "HW-1")
+	_, err = RegisterLicenseFrom("10.0.0.1", unknownKey, "HW-1")
+	if !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("attempt after unlock = %v, want %v", err, ErrInvalidKey)
+	}
+}
@@ -0,0 +1,127 @@
+/*
+ * File: tokens.go
+ * File Created: Monday, 19th October 2026 10:36:50 am
+ * Last Modified: Monday, 19th October 2026 3:10:59 pm
+ * Author: Akhil Datla
+ * Copyright © Akhil Datla 2023
+ */
+
+package licensing
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"main/backend/courses"
+)
+
+// Claims represents what a license token entitles a device to. Times are Unix seconds and a zero
+// ExpiresAt means the entitlement is perpetual.
+type Claims struct {
+	LicenseID  string   `json:"lid,omitempty"`
+	CourseIDs  []string `json:"cid"`
+	HardwareID string   `json:"hid"`
+	IssuedAt   int64    `json:"iat"`
+	ExpiresAt  int64    `json:"exp,omitempty"`
+}
+
+// issueToken signs the claims into a license token.
+func issueToken(claims Claims) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	return sign(payload)
+}
+
+// issueTokens signs a license token for every license the active entitlements were activated
+// from. Entitlements from before licenses were kept are grouped under an empty license ID.
+// Revoked and expired entitlements and courses that are not published are left out.
+func issueTokens(hardwareID string, active []Entitlement, issuedAt time.Time) ([]string, error) {
+	grouped := make(map[string]*Claims)
+	licenseIDs := make([]string, 0)
+	published := make(map[string]bool)
+
+	for _, entitlement := range active {
+		if entitlement.IsRevoked() || entitlement.IsExpired(issuedAt) {
+			continue
+		}
+		isPublished, ok := published[entitlement.CourseID]
+		if !ok {
+			course, err := courses.GetCourse(entitlement.CourseID)
+			isPublished = err == nil && course.IsPublished(issuedAt)
+			published[entitlement.CourseID] = isPublished
+		}
+		if !isPublished {
+			continue
+		}
+
+		claims, ok := grouped[entitlement.LicenseID]
+		if !ok {
+			claims = &Claims{
+				LicenseID:  entitlement.LicenseID,
+				CourseIDs:  make([]string, 0),
+				HardwareID: hardwareID,
+				IssuedAt:   issuedAt.Unix(),
+				ExpiresAt:  -1,
+			}
+			grouped[entitlement.LicenseID] = claims
+			licenseIDs = append(licenseIDs, entitlement.LicenseID)
+		}
+
+		if !contains(claims.CourseIDs, entitlement.CourseID) {
+			claims.CourseIDs = append(claims.CourseIDs, entitlement.CourseID)
+		}
+
+		// A token expires with the earliest of its time-limited entitlements
+		if !entitlement.ExpiresAt.IsZero() && (claims.ExpiresAt <= 0 || entitlement.ExpiresAt.Unix() < claims.ExpiresAt) {
+			claims.ExpiresAt = entitlement.ExpiresAt.Unix()
+		}
+	}
+
+	sort.Strings(licenseIDs)
+	tokens := make([]string, 0, len(licenseIDs))
+	for _, licenseID := range licenseIDs {
+		claims := grouped[licenseID]
+		if claims.ExpiresAt < 0 {
+			claims.ExpiresAt = 0
+		}
+
+		token, err := issueToken(*claims)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+
+	return tokens, nil
+}
+
+// VerifyToken checks a license token fully offline: its signature against the server public key,
+// that it was issued to the hardware ID, and that it has not expired at the given time. It returns
+// the claims of a valid token.
+func VerifyToken(token string, publicKey ed25519.PublicKey, hardwareID string, now time.Time) (Claims, error) {
+	payload, err := verify(token, publicKey)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	var claims Claims
+	err = json.Unmarshal(payload, &claims)
+	if err != nil {
+		return Claims{}, errors.New("malformed token")
+	}
+
+	if claims.HardwareID != hardwareID {
+		return Claims{}, errors.New("token issued to another device")
+	}
+	if claims.ExpiresAt != 0 && now.Unix() >= claims.ExpiresAt {
+		return Claims{}, errors.New("token expired")
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,192 @@
+/*
+ * File: transfer.go
+ * File Created: Monday, 19th October 2026 10:14:38 am
+ * Last Modified: Monday, 19th October 2026 5:10:12 pm
+ * Author: Akhil Datla
+ * Copyright © Akhil Datla 2023
+ */
+
+package licensing
+
+import (
+	"errors"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"main/backend/dbmanager"
+)
+
+var (
+	// MaxTransfers is the number of times an entitlement may move to another hardware ID.
+	MaxTransfers = 3
+
+	// TransferInterval is the time that must pass before an entitlement may move again.
+	TransferInterval = 30 * 24 * time.Hour
+)
+
+// Transfer records the move of entitlements from one hardware ID to another.
+type Transfer struct {
+	ID             string `storm:"id"`
+	FromHardwareID string `storm:"index"`
+	ToHardwareID   string `storm:"index"`
+	EntitlementIDs []string
+	CreatedAt      time.Time
+}
+
+// DeactivateEntitlement deletes the entitlement of the organization with the given ID, returning
+// its seat to the license.
+func DeactivateEntitlement(organizationID, entitlementID string) error {
+	registerMutex.Lock()
+	defer registerMutex.Unlock()
+
+	var entitlement Entitlement
+	err := dbmanager.OrganizationQuery(organizationID, "ID", entitlementID, &entitlement)
+	if err != nil {
+		return errors.New("invalid entitlement id")
+	}
+
+	// Revoked entitlements are kept so that the course cannot be activated again
+	if entitlement.IsRevoked() {
+		return errors.New("entitlement revoked")
+	}
+
+	return deactivate(entitlement)
+}
+
+// DeactivateDevice deletes the entitlements the hardware ID holds through the license, returning its
+// seat. Knowing the license key together with the hardware ID proves that the caller holds the seat.
+// Revoked entitlements are kept so that deactivating cannot undo a revocation.
+func DeactivateDevice(licenseID, hardwareID string) error {
+	registerMutex.Lock()
+	defer registerMutex.Unlock()
+
+	activations, err := GetLicenseActivations(licenseID)
+	if err != nil {
+		return err
+	}
+
+	deactivated, revoked := 0, 0
+	for _, activation := range activations {
+		if activation.HardwareID != hardwareID {
+			continue
+		}
+		if activation.IsRevoked() {
+			revoked++
+			continue
+		}
+		if err := deactivate(activation); err != nil {
+			return err
+		}
+		deactivated++
+	}
+
+	if deactivated == 0 && revoked > 0 {
+		return errors.New("license revoked on this device")
+	}
+	if deactivated == 0 {
+		return errors.New("license not activated on this device")
+	}
+	return nil
+}
+
+// deactivate deletes the entitlement and records the returned seat in the history of its license.
+func deactivate(entitlement Entitlement) error {
+	err := dbmanager.Delete(&entitlement)
+	if err != nil {
+		return err
+	}
+
+	recordEvent(entitlement.LicenseID, EventDeactivated, entitlement.HardwareID, "")
+	return nil
+}
+
+// TransferEntitlements moves every entitlement of the organization from the old hardware ID to the
+// new one, except revoked ones. Nothing is moved if any entitlement was moved MaxTransfers times already or less than
+// TransferInterval ago.
+func TransferEntitlements(organizationID, fromHardwareID, toHardwareID string) (Transfer, error) {
+	registerMutex.Lock()
+	defer registerMutex.Unlock()
+
+	if fromHardwareID == "" || toHardwareID == "" || fromHardwareID == toHardwareID {
+		return Transfer{}, errors.New("invalid hardware ids")
+	}
+
+	var found []Entitlement
+	err := dbmanager.OrganizationGroupQuery(organizationID, "HardwareID", fromHardwareID, &found)
+	if err != nil && !dbmanager.IsNotFound(err) {
+		return Transfer{}, err
+	}
+
+	// Revoked entitlements stay with the old hardware ID
+	entitlements := make([]Entitlement, 0, len(found))
+	for _, entitlement := range found {
+		if !entitlement.IsRevoked() {
+			entitlements = append(entitlements, entitlement)
+		}
+	}
+	if len(entitlements) == 0 {
+		return Transfer{}, errors.New("no entitlements found")
+	}
+
+	// Check the limits before moving anything
+	now := time.Now()
+	for _, entitlement := range entitlements {
+		if entitlement.Transfers >= MaxTransfers {
+			return Transfer{}, errors.New("transfer limit reached")
+		}
+		if !entitlement.TransferAt.IsZero() && now.Sub(entitlement.TransferAt) < TransferInterval {
+			return Transfer{}, errors.New("transferred too recently")
+		}
+	}
+
+	// Licenses the new hardware ID already holds a seat of
+	var existing []Entitlement
+	err = dbmanager.GroupQuery("HardwareID", toHardwareID, &existing)
+	if err != nil && !dbmanager.IsNotFound(err) {
+		return Transfer{}, err
+	}
+	held := make(map[string]bool)
+	for _, entitlement := range existing {
+		if entitlement.LicenseID != "" {
+			held[entitlement.LicenseID] = true
+		}
+	}
+
+	transfer := Transfer{
+		ID:             uuid.NewV4().String(),
+		FromHardwareID: fromHardwareID,
+		ToHardwareID:   toHardwareID,
+		EntitlementIDs: make([]string, 0),
+		CreatedAt:      now,
+	}
+
+	for _, entitlement := range entitlements {
+		// A second seat of the same license would be wasted, and a course revoked for the new
+		// hardware ID cannot move to it, so return the seat instead
+		revoked, err := revokedFor(entitlement, toHardwareID)
+		if err != nil {
+			return transfer, err
+		}
+		if (entitlement.LicenseID != "" && held[entitlement.LicenseID]) || revoked {
+			err = deactivate(entitlement)
+			if err != nil {
+				return transfer, err
+			}
+			continue
+		}
+
+		entitlement.HardwareID = toHardwareID
+		entitlement.Transfers++
+		entitlement.TransferAt = now
+		err = dbmanager.Save(&entitlement)
+		if err != nil {
+			return transfer, err
+		}
+		transfer.EntitlementIDs = append(transfer.EntitlementIDs, entitlement.ID)
+		recordEvent(entitlement.LicenseID, EventTransferred, toHardwareID, "from "+fromHardwareID)
+	}
+
+	err = dbmanager.Save(&transfer)
+	return transfer, err
+}
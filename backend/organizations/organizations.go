@@ -0,0 +1,303 @@
+/*
+ * File: organizations.go
+ * File Created: Monday, 19th October 2026 11:41:41 am
+ * Last Modified: Monday, 19th October 2026 2:48:58 pm
+ * Author: Akhil Datla
+ * Copyright © Akhil Datla 2023
+ */
+
+package organizations
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"main/backend/branding"
+	"main/backend/dbmanager"
+	"main/backend/security"
+)
+
+// Default is the ID of the organization that runs the server. Records from before organizations
+// were introduced belong to it, and only its admins manage the other organizations.
+const Default = ""
+
+// DefaultHomepage is the homepage of packages for organizations without a homepage of their own.
+const DefaultHomepage = "homepage.md"
+
+// Root is the directory holding a folder per organization, under which its admins keep course
+// folders, imports and exports. When empty, it is an "organizations" directory next to the database.
+var Root = ""
+
+// Organization represents a school or NGO served by the server. Its courses, licenses and
+// entitlements are only visible to its own admins.
+type Organization struct {
+	ID        string    `storm:"id" json:"id"`
+	Name      string    `storm:"unique" json:"name"`
+	Homepage  string    `json:"homepage"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Admin represents a person managing an organization through the server with an admin key.
+type Admin struct {
+	ID             string    `storm:"id" json:"id"`
+	OrganizationID string    `storm:"index" json:"organizationID"`
+	Name           string    `json:"name"`
+	KeyHash        string    `storm:"unique" json:"-"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+// checkHomepage checks that the homepage, if given, is a Markdown file that exists.
+func checkHomepage(homepage string) error {
+	if homepage == "" {
+		return nil
+	}
+
+	info, err := os.Stat(homepage)
+	if err != nil || info.IsDir() {
+		return errors.New("invalid homepage filepath")
+	}
+	return nil
+}
+
+// CreateOrganization creates a new organization with the given name and homepage Markdown file.
+func CreateOrganization(name, homepage string) (string, error) {
+	if name == "" {
+		return "", errors.New("invalid organization name")
+	}
+	if err := checkHomepage(homepage); err != nil {
+		return "", err
+	}
+
+	organization := &Organization{
+		ID:        uuid.NewV4().String(),
+		Name:      name,
+		Homepage:  homepage,
+		CreatedAt: time.Now(),
+	}
+
+	// Save the organization to the database
+	err := dbmanager.Save(organization)
+	return organization.ID, err
+}
+
+// GetOrganization retrieves an organization by its ID.
+func GetOrganization(id string) (Organization, error) {
+	var organization Organization
+	err := dbmanager.Query("ID", id, &organization)
+	if err != nil {
+		return Organization{}, errors.New("invalid organization id")
+	}
+	return organization, nil
+}
+
+// GetAllOrganizations retrieves all organizations.
+func GetAllOrganizations() ([]Organization, error) {
+	var organizations []Organization
+	err := dbmanager.QueryAll(&organizations)
+	return organizations, err
+}
+
+// UpdateOrganization updates the name and homepage of the organization with the given ID.
+// Empty values are left as they are.
+func UpdateOrganization(id, name, homepage string) error {
+	if _, err := GetOrganization(id); err != nil {
+		return err
+	}
+	if err := checkHomepage(homepage); err != nil {
+		return err
+	}
+
+	return dbmanager.Update(&Organization{ID: id, Name: name, Homepage: homepage})
+}
+
+// DeleteOrganization deletes the organization with the given ID together with its admins and branding.
+func DeleteOrganization(id string) error {
+	organization, err := GetOrganization(id)
+	if err != nil {
+		return err
+	}
+
+	admins, _ := GetAdmins(id)
+	for i := range admins {
+		dbmanager.Delete(&admins[i])
+	}
+	branding.DeleteBranding(BrandingOwner(id))
+
+	return dbmanager.Delete(&organization)
+}
+
+// Homepage returns the homepage Markdown file of the organization with the given ID.
+func Homepage(id string) string {
+	if id == Default {
+		return DefaultHomepage
+	}
+
+	organization, err := GetOrganization(id)
+	if err != nil || organization.Homepage == "" {
+		return DefaultHomepage
+	}
+	return organization.Homepage
+}
+
+// Dir returns the folder of the organization with the given ID under Root.
+func Dir(id string) string {
+	root := Root
+	if root == "" {
+		root = filepath.Join(filepath.Dir(dbmanager.Path()), "organizations")
+	}
+	return filepath.Join(root, id)
+}
+
+// ResolvePath returns the server path of a path given by an admin of the organization with the
+// given ID. Admins of the default organization use server paths as they are. Paths of other
+// organizations are relative to the folder of the organization and may not leave it, not even
+// through symbolic links.
+func ResolvePath(organizationID, path string) (string, error) {
+	if organizationID == Default {
+		return path, nil
+	}
+	if path == "" || filepath.IsAbs(path) || filepath.VolumeName(path) != "" {
+		return "", errors.New("invalid path")
+	}
+
+	dir := Dir(organizationID)
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return "", err
+	}
+	dir, err = filepath.EvalSymlinks(dir)
+	if err != nil {
+		return "", err
+	}
+
+	resolved := filepath.Join(dir, path)
+	if !within(dir, resolved) {
+		return "", errors.New("invalid path")
+	}
+
+	// Resolve the symbolic links of the part of the path that already exists
+	existing := resolved
+	for {
+		real, err := filepath.EvalSymlinks(existing)
+		if err == nil {
+			if !within(dir, real) {
+				return "", errors.New("invalid path")
+			}
+			break
+		}
+		existing = filepath.Dir(existing)
+	}
+
+	return resolved, nil
+}
+
+// within reports whether the path lies inside the directory or is the directory itself.
+func within(dir, path string) bool {
+	relative, err := filepath.Rel(dir, path)
+	return err == nil && relative != ".." && !strings.HasPrefix(relative, ".."+string(filepath.Separator))
+}
+
+// BrandingOwner returns the branding owner ID of the organization with the given ID. The default
+// organization owns the default branding, which other organizations override with their own.
+func BrandingOwner(id string) string {
+	if id == Default {
+		return branding.DefaultOwner
+	}
+	return id
+}
+
+// CreateAdmin creates an admin of the organization with the given ID and returns it together with
+// its admin key. Only a hash of the key is stored, so the key cannot be retrieved again.
+// The first admin has to be one of the default organization, as admin keys are required from then on.
+func CreateAdmin(organizationID, name string) (Admin, string, error) {
+	if name == "" {
+		return Admin{}, "", errors.New("invalid admin name")
+	}
+	if organizationID != Default {
+		if _, err := GetOrganization(organizationID); err != nil {
+			return Admin{}, "", err
+		}
+		if admins, _ := GetAdmins(Default); len(admins) == 0 {
+			return Admin{}, "", errors.New("a server admin is required first")
+		}
+	}
+
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return Admin{}, "", err
+	}
+	key := hex.EncodeToString(random)
+
+	admin := Admin{
+		ID:             uuid.NewV4().String(),
+		OrganizationID: organizationID,
+		Name:           name,
+		KeyHash:        security.Hash([]byte(key)),
+		CreatedAt:      time.Now(),
+	}
+
+	// Save the admin to the database
+	err := dbmanager.Save(&admin)
+	return admin, key, err
+}
+
+// GetAdmin retrieves an admin by its ID.
+func GetAdmin(id string) (Admin, error) {
+	var admin Admin
+	err := dbmanager.Query("ID", id, &admin)
+	if err != nil {
+		return Admin{}, errors.New("invalid admin id")
+	}
+	return admin, nil
+}
+
+// GetAdmins retrieves the admins of the organization with the given ID.
+func GetAdmins(organizationID string) ([]Admin, error) {
+	var admins []Admin
+	err := dbmanager.OrganizationQueryAll(organizationID, &admins)
+	if dbmanager.IsNotFound(err) {
+		return make([]Admin, 0), nil
+	}
+	return admins, err
+}
+
+// DeleteAdmin deletes the admin with the given ID, invalidating its admin key. The last admin of
+// the default organization cannot be deleted, so the server always stays manageable.
+func DeleteAdmin(id string) error {
+	admin, err := GetAdmin(id)
+	if err != nil {
+		return err
+	}
+	if admin.OrganizationID == Default {
+		if admins, _ := GetAdmins(Default); len(admins) <= 1 {
+			return errors.New("cannot delete the last server admin")
+		}
+	}
+	return dbmanager.Delete(&admin)
+}
+
+// Authenticate retrieves the admin holding the given admin key.
+func Authenticate(key string) (Admin, error) {
+	var admin Admin
+	err := dbmanager.Query("KeyHash", security.Hash([]byte(key)), &admin)
+	if err != nil {
+		return Admin{}, errors.New("invalid admin key")
+	}
+	return admin, nil
+}
+
+// KeysRequired reports whether any admin exists. Until then the server is managed without admin
+// keys on behalf of the default organization, as before organizations were introduced. Keys are
+// required if the admins cannot be read.
+func KeysRequired() bool {
+	var admins []Admin
+	err := dbmanager.QueryAll(&admins)
+	return err != nil || len(admins) > 0
+}
@@ -0,0 +1,280 @@
+/*
+ * File: quizzes.go
+ * File Created: Monday, 19th October 2026 8:02:32 am
+ * Last Modified: Monday, 19th October 2026 8:02:32 am
+ * Author: Akhil Datla
+ * Copyright © Akhil Datla 2023
+ */
+
+package quizzes
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// QuizExtension is the file name suffix of quiz files inside a course folder.
+const QuizExtension = ".quiz.json"
+
+// Question types supported in quiz files.
+const (
+	MultipleChoice = "multiple-choice"
+	TrueFalse      = "true-false"
+	Numeric        = "numeric"
+	ShortAnswer    = "short-answer"
+)
+
+// Quiz represents a quiz file in a course folder.
+type Quiz struct {
+	Title     string     `json:"title"`
+	Weight    int        `json:"weight,omitempty"`
+	PassMark  int        `json:"passMark,omitempty"`
+	Questions []Question `json:"questions"`
+}
+
+// Question represents a single quiz question together with its answer key.
+// Multiple choice answers are the index of the correct choice, true/false answers are
+// booleans, numeric answers are compared at the given number of decimals, and short
+// answers accept any of the listed answers ignoring case and spacing.
+type Question struct {
+	Type     string          `json:"type"`
+	Prompt   string          `json:"prompt"`
+	Choices  []string        `json:"choices,omitempty"`
+	Answer   json.RawMessage `json:"answer,omitempty"`
+	Answers  []string        `json:"answers,omitempty"`
+	Decimals int             `json:"decimals,omitempty"`
+}
+
+// ReadQuiz reads and validates the quiz file at the given path.
+func ReadQuiz(path string) (Quiz, error) {
+	var quiz Quiz
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return quiz, err
+	}
+
+	err = json.Unmarshal(data, &quiz)
+	if err != nil {
+		return quiz, fmt.Errorf("%s: %w", path, err)
+	}
+
+	err = quiz.Validate()
+	if err != nil {
+		return quiz, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return quiz, nil
+}
+
+// Validate checks that the quiz is complete and that every question has a valid answer key.
+func (q Quiz) Validate() error {
+	if q.Title == "" {
+		return errors.New("missing quiz title")
+	}
+
+	if len(q.Questions) == 0 {
+		return errors.New("quiz has no questions")
+	}
+
+	if q.PassMark < 0 || q.PassMark > 100 {
+		return errors.New("pass mark must be between 0 and 100")
+	}
+
+	for i, question := range q.Questions {
+		if question.Prompt == "" {
+			return fmt.Errorf("question %d: missing prompt", i+1)
+		}
+
+		_, err := question.keys()
+		if err != nil {
+			return fmt.Errorf("question %d: %w", i+1, err)
+		}
+	}
+
+	return nil
+}
+
+// keys returns the normalized accepted answers of the question.
+func (q Question) keys() ([]string, error) {
+	switch q.Type {
+	case MultipleChoice:
+		if len(q.Choices) < 2 {
+			return nil, errors.New("multiple choice needs at least two choices")
+		}
+		var answer int
+		if err := json.Unmarshal(q.Answer, &answer); err != nil || answer < 0 || answer >= len(q.Choices) {
+			return nil, errors.New("answer must be the index of a choice")
+		}
+		return []string{strconv.Itoa(answer)}, nil
+
+	case TrueFalse:
+		var answer bool
+		if err := json.Unmarshal(q.Answer, &answer); err != nil {
+			return nil, errors.New("answer must be true or false")
+		}
+		return []string{strconv.FormatBool(answer)}, nil
+
+	case Numeric:
+		var answer float64
+		if err := json.Unmarshal(q.Answer, &answer); err != nil {
+			return nil, errors.New("answer must be a number")
+		}
+		if q.Decimals < 0 || q.Decimals > 10 {
+			return nil, errors.New("decimals must be between 0 and 10")
+		}
+		return []string{strconv.FormatFloat(answer, 'f', q.Decimals, 64)}, nil
+
+	case ShortAnswer:
+		keys := make([]string, 0)
+		for _, answer := range q.Answers {
+			if normalized := normalize(answer); normalized != "" {
+				keys = append(keys, normalized)
+			}
+		}
+		if len(keys) == 0 {
+			return nil, errors.New("short answer needs at least one accepted answer")
+		}
+		return keys, nil
+	}
+
+	return nil, fmt.Errorf("unknown question type %q", q.Type)
+}
+
+// normalize lowercases the answer and collapses whitespace.
+func normalize(answer string) string {
+	return strings.Join(strings.Fields(strings.ToLower(answer)), " ")
+}
+
+// hashKey hashes a normalized answer with the quiz salt.
+func hashKey(salt, key string) string {
+	sum := sha256.Sum256([]byte(salt + ":" + key))
+	return hex.EncodeToString(sum[:])
+}
+
+// FindQuizzes returns the paths of every quiz file under the given directory.
+func FindQuizzes(dir string) ([]string, error) {
+	paths := make([]string, 0)
+
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.IsDir() && strings.HasSuffix(d.Name(), QuizExtension) {
+			paths = append(paths, path)
+		}
+
+		return nil
+	})
+
+	return paths, err
+}
+
+// ValidateDir validates every quiz file under the given directory.
+func ValidateDir(dir string) error {
+	paths, err := FindQuizzes(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		_, err = ReadQuiz(path)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RenderDir replaces every quiz file under the given Hugo content directory with a page
+// that renders the quiz interactively. Answer keys are stored only as salted hashes.
+func RenderDir(dir string) error {
+	paths, err := FindQuizzes(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		quiz, err := ReadQuiz(path)
+		if err != nil {
+			return err
+		}
+
+		page, err := renderPage(quiz)
+		if err != nil {
+			return err
+		}
+
+		// Write the page next to the quiz file and remove the readable answer key
+		pagePath := strings.TrimSuffix(path, QuizExtension) + "-quiz.md"
+		err = os.WriteFile(pagePath, page, 0666)
+		if err != nil {
+			return err
+		}
+
+		err = os.Remove(path)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// renderPage creates a Hugo page with JSON front matter holding the quiz without its answers.
+func renderPage(quiz Quiz) ([]byte, error) {
+	saltBytes := make([]byte, 16)
+	if _, err := rand.Read(saltBytes); err != nil {
+		return nil, err
+	}
+	salt := hex.EncodeToString(saltBytes)
+
+	// Hugo lowercases parameter names, so the front matter uses lowercase keys
+	questions := make([]map[string]interface{}, 0)
+	for _, question := range quiz.Questions {
+		keys, err := question.keys()
+		if err != nil {
+			return nil, err
+		}
+
+		hashes := make([]string, 0)
+		for _, key := range keys {
+			hashes = append(hashes, hashKey(salt, key))
+		}
+
+		questions = append(questions, map[string]interface{}{
+			"type":     question.Type,
+			"prompt":   question.Prompt,
+			"choices":  question.Choices,
+			"decimals": question.Decimals,
+			"hashes":   hashes,
+		})
+	}
+
+	frontMatter := map[string]interface{}{
+		"title":  quiz.Title,
+		"weight": quiz.Weight,
+		"quiz": map[string]interface{}{
+			"salt":      salt,
+			"passmark":  quiz.PassMark,
+			"questions": questions,
+		},
+	}
+
+	data, err := json.MarshalIndent(frontMatter, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(data, []byte("\n\n{{< quiz >}}\n")...), nil
+}
@@ -0,0 +1,214 @@
+/*
+ * File: admin.go
+ * File Created: Monday, 19th October 2026 9:19:03 am
+ * Last Modified: Monday, 19th October 2026 3:53:06 pm
+ * Author: Akhil Datla
+ * Copyright © Akhil Datla 2023
+ */
+
+package search
+
+import (
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// snippetContext is the number of characters shown around a match in a snippet.
+const snippetContext = 60
+
+// AdminLanguage is the language used to tokenize course sources for administrator search.
+var AdminLanguage = DefaultLanguage
+
+// Page represents a Markdown page or an asset file of a course source.
+type Page struct {
+	Path  string
+	Title string
+	Text  string
+	Asset bool
+}
+
+// Hit represents a search result.
+type Hit struct {
+	CourseID   string `json:"courseID"`
+	CourseName string `json:"courseName"`
+	Page       string `json:"page"`
+	Title      string `json:"title"`
+	Snippet    string `json:"snippet"`
+	Asset      bool   `json:"asset"`
+	Score      int    `json:"score"`
+}
+
+// indexedPage holds a page together with its term frequencies.
+type indexedPage struct {
+	Page
+	terms map[string]int
+}
+
+// indexedCourse holds the indexed pages of a course.
+type indexedCourse struct {
+	name  string
+	pages []indexedPage
+}
+
+var (
+	adminMutex   sync.RWMutex
+	adminCourses = make(map[string]indexedCourse)
+
+	frontMatterPattern = regexp.MustCompile(`(?s)\A(---|\+\+\+)\r?\n(.*?)\r?\n(---|\+\+\+)\r?\n`)
+	titleFieldPattern  = regexp.MustCompile(`(?m)^title\s*[:=]\s*["']?(.*?)["']?\s*$`)
+	headingPattern     = regexp.MustCompile(`(?m)^#\s+(.+)$`)
+	markdownPattern    = regexp.MustCompile(`[#*_>` + "`" + `\[\]()!|{}<>]+`)
+)
+
+// MarkdownPage extracts the title and text of a Markdown source file.
+func MarkdownPage(pagePath string, content []byte) Page {
+	text := string(content)
+	title := ""
+
+	// Take the title from the front matter and drop the front matter from the text
+	if match := frontMatterPattern.FindStringSubmatch(text); match != nil {
+		if field := titleFieldPattern.FindStringSubmatch(match[2]); field != nil {
+			title = field[1]
+		}
+		text = text[len(match[0]):]
+	}
+
+	// Fall back to the first heading
+	if title == "" {
+		if match := headingPattern.FindStringSubmatch(text); match != nil {
+			title = strings.TrimSpace(match[1])
+		}
+	}
+
+	text = markdownPattern.ReplaceAllString(text, " ")
+	text = strings.TrimSpace(spacePattern.ReplaceAllString(text, " "))
+
+	return Page{Path: pagePath, Title: title, Text: text}
+}
+
+// AssetPage returns a page for an asset file, which is found by its name.
+func AssetPage(assetPath string) Page {
+	return Page{Path: assetPath, Title: path.Base(assetPath), Asset: true}
+}
+
+// IndexCourse replaces the indexed pages of the course with the given pages.
+func IndexCourse(courseID, courseName string, pages []Page) {
+	_, lang := GetLanguage(AdminLanguage)
+
+	course := indexedCourse{
+		name:  courseName,
+		pages: make([]indexedPage, 0, len(pages)),
+	}
+
+	for _, page := range pages {
+		terms := make(map[string]int)
+		for _, token := range lang.Tokenize(page.Title + " " + page.Path) {
+			terms[token] += 3
+		}
+		for _, token := range lang.Tokenize(page.Text) {
+			terms[token]++
+		}
+		course.pages = append(course.pages, indexedPage{Page: page, terms: terms})
+	}
+
+	adminMutex.Lock()
+	adminCourses[courseID] = course
+	adminMutex.Unlock()
+}
+
+// RemoveCourse removes the course with the given ID from the index.
+func RemoveCourse(courseID string) {
+	adminMutex.Lock()
+	delete(adminCourses, courseID)
+	adminMutex.Unlock()
+}
+
+// Search returns the pages and assets matching every term of the query, best matches first.
+func Search(query string, limit int) []Hit {
+	_, lang := GetLanguage(AdminLanguage)
+	tokens := lang.Tokenize(query)
+	hits := make([]Hit, 0)
+	if len(tokens) == 0 {
+		return hits
+	}
+
+	adminMutex.RLock()
+	for courseID, course := range adminCourses {
+		for _, page := range course.pages {
+			score := 0
+			for _, token := range tokens {
+				count := page.terms[token]
+				if count == 0 {
+					score = 0
+					break
+				}
+				score += count
+			}
+			if score == 0 {
+				continue
+			}
+
+			hits = append(hits, Hit{
+				CourseID:   courseID,
+				CourseName: course.name,
+				Page:       page.Path,
+				Title:      page.Title,
+				Snippet:    snippet(page.Text, query),
+				Asset:      page.Asset,
+				Score:      score,
+			})
+		}
+	}
+	adminMutex.RUnlock()
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].CourseName+hits[i].Page < hits[j].CourseName+hits[j].Page
+	})
+
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits
+}
+
+// snippet returns the part of the text around the first query word it contains.
+func snippet(text, query string) string {
+	// Match on the text itself so that offsets stay valid where lowercasing changes lengths
+	position := -1
+	for _, word := range strings.Fields(query) {
+		loc := regexp.MustCompile("(?i)" + regexp.QuoteMeta(word)).FindStringIndex(text)
+		if loc != nil && (position < 0 || loc[0] < position) {
+			position = loc[0]
+		}
+	}
+
+	if position < 0 {
+		return truncate(text, 2*snippetContext)
+	}
+
+	runes := []rune(text)
+	start := utf8.RuneCountInString(text[:position]) - snippetContext
+	if start < 0 {
+		start = 0
+	}
+	end := start + 2*snippetContext
+	if end > len(runes) {
+		end = len(runes)
+	}
+
+	result := string(runes[start:end])
+	if start > 0 {
+		result = "…" + result
+	}
+	if end < len(runes) {
+		result += "…"
+	}
+	return result
+}
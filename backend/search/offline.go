@@ -0,0 +1,169 @@
+/*
+ * File: offline.go
+ * File Created: Monday, 19th October 2026 9:08:21 am
+ * Last Modified: Monday, 19th October 2026 9:08:21 am
+ * Author: Akhil Datla
+ * Copyright © Akhil Datla 2023
+ */
+
+package search
+
+import (
+	"encoding/json"
+	"html"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// IndexFileName is the name of the script holding the offline search index inside a website.
+const IndexFileName = "search-index.js"
+
+// SearchSection is the content section of the search page, which is left out of the index.
+const SearchSection = "search"
+
+// snippetLength is the maximum number of characters kept as a page snippet.
+const snippetLength = 160
+
+// Document represents an indexed page. URL is relative to the website root.
+type Document struct {
+	URL     string `json:"u"`
+	Title   string `json:"t"`
+	Snippet string `json:"s"`
+}
+
+// OfflineIndex is the compact search index shipped in every package. Terms maps each stem to a
+// flat list of document index and term frequency pairs.
+type OfflineIndex struct {
+	Version  int              `json:"version"`
+	Lang     string           `json:"lang"`
+	Language *Language        `json:"language"`
+	MinStem  int              `json:"minStem"`
+	Docs     []Document       `json:"docs"`
+	Terms    map[string][]int `json:"terms"`
+}
+
+var (
+	langPattern      = regexp.MustCompile(`(?is)<html[^>]*\slang="([^"]+)"`)
+	titlePattern     = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	bodyInnerPattern = regexp.MustCompile(`(?is)<div[^>]*id="body-inner"[^>]*>(.*)`)
+	blockPatterns    = []*regexp.Regexp{
+		regexp.MustCompile(`(?is)<script\b.*?</script>`),
+		regexp.MustCompile(`(?is)<style\b.*?</style>`),
+		regexp.MustCompile(`(?is)<nav\b.*?</nav>`),
+		regexp.MustCompile(`(?is)<footer\b.*?</footer>`),
+	}
+	tagPattern   = regexp.MustCompile(`(?s)<[^>]*>`)
+	spacePattern = regexp.MustCompile(`\s+`)
+)
+
+// PageText extracts the title and visible text of a rendered HTML page.
+func PageText(page string) (string, string) {
+	title := ""
+	if match := titlePattern.FindStringSubmatch(page); match != nil {
+		title = strings.TrimSpace(html.UnescapeString(match[1]))
+	}
+
+	// Prefer the main content area of the theme over the whole page
+	if match := bodyInnerPattern.FindStringSubmatch(page); match != nil {
+		page = match[1]
+	}
+
+	for _, pattern := range blockPatterns {
+		page = pattern.ReplaceAllString(page, " ")
+	}
+	text := html.UnescapeString(tagPattern.ReplaceAllString(page, " "))
+	text = strings.TrimSpace(spacePattern.ReplaceAllString(text, " "))
+
+	return title, text
+}
+
+// BuildOfflineIndex builds a search index over the HTML pages of a website file map.
+func BuildOfflineIndex(m map[string][]byte) OfflineIndex {
+	paths := make([]string, 0)
+	for path, data := range m {
+		name := filepath.ToSlash(path)
+		if len(data) == 0 || !strings.HasSuffix(name, ".html") || name == "404.html" {
+			continue
+		}
+		if name == SearchSection+".html" || strings.HasPrefix(name, SearchSection+"/") {
+			continue
+		}
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	// Use the language of the first page that declares one
+	code := DefaultLanguage
+	for _, path := range paths {
+		if match := langPattern.FindSubmatch(m[path]); match != nil {
+			code = string(match[1])
+			break
+		}
+	}
+	code, lang := GetLanguage(code)
+
+	index := OfflineIndex{
+		Version:  1,
+		Lang:     code,
+		Language: lang,
+		MinStem:  minStemLength,
+		Docs:     make([]Document, 0),
+		Terms:    make(map[string][]int),
+	}
+
+	for _, path := range paths {
+		title, text := PageText(string(m[path]))
+
+		// Link to directories for pretty URLs and to files otherwise
+		url := strings.TrimSuffix(filepath.ToSlash(path), "index.html")
+
+		docIndex := len(index.Docs)
+		index.Docs = append(index.Docs, Document{
+			URL:     url,
+			Title:   title,
+			Snippet: truncate(text, snippetLength),
+		})
+
+		// Count term frequencies, weighting title words higher
+		counts := make(map[string]int)
+		for _, token := range lang.Tokenize(title) {
+			counts[token] += 3
+		}
+		for _, token := range lang.Tokenize(text) {
+			counts[token]++
+		}
+
+		for token, count := range counts {
+			index.Terms[token] = append(index.Terms[token], docIndex, count)
+		}
+	}
+
+	return index
+}
+
+// Script returns the index as a script assigning it to window.LEARNADO_SEARCH_INDEX, so that it
+// can be loaded from the local file system without fetching.
+func (index OfflineIndex) Script() ([]byte, error) {
+	data, err := json.Marshal(index)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte("window.LEARNADO_SEARCH_INDEX = " + string(data) + ";\n"), nil
+}
+
+// truncate shortens the text to at most n characters, cutting at a word boundary.
+func truncate(text string, n int) string {
+	runes := []rune(text)
+	if len(runes) <= n {
+		return text
+	}
+
+	cut := string(runes[:n])
+	if i := strings.LastIndex(cut, " "); i > 0 {
+		cut = cut[:i]
+	}
+	return cut + "…"
+}
@@ -0,0 +1,118 @@
+/*
+ * File: tokenize.go
+ * File Created: Monday, 19th October 2026 9:08:21 am
+ * Last Modified: Monday, 19th October 2026 9:08:21 am
+ * Author: Akhil Datla
+ * Copyright © Akhil Datla 2023
+ */
+
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// DefaultLanguage is used when a page does not declare a supported language.
+const DefaultLanguage = "en"
+
+// minStemLength is the shortest stem a suffix rule may leave behind.
+const minStemLength = 3
+
+// Language holds the stop words and suffix rules used to tokenize text in one language.
+// Suffix rules are tried in order and the first matching rule is applied.
+type Language struct {
+	StopWords []string    `json:"stop"`
+	Suffixes  [][2]string `json:"suffixes"`
+	stopSet   map[string]bool
+}
+
+// Languages lists the supported languages by their ISO 639-1 code.
+var Languages = map[string]*Language{
+	"en": {
+		StopWords: strings.Fields(`a an and are as at be but by for from has have he her his i if in into is it its
+			no not of on or our she so such that the their then there these they this to was we were what when which
+			who will with you your`),
+		Suffixes: [][2]string{
+			{"ational", "ate"}, {"ization", "ize"}, {"fulness", "ful"}, {"ousness", "ous"}, {"iveness", "ive"},
+			{"sses", "ss"}, {"ies", "y"}, {"ingly", ""}, {"edly", ""}, {"ing", ""}, {"ed", ""}, {"ly", ""},
+			{"es", ""}, {"ss", "ss"}, {"s", ""},
+		},
+	},
+	"es": {
+		StopWords: strings.Fields(`a al como con de del el en es esta este la las lo los más no o para pero por que
+			se sin sobre su sus un una uno y`),
+		Suffixes: [][2]string{
+			{"amiento", ""}, {"imiento", ""}, {"aciones", ""}, {"ación", ""}, {"mente", ""}, {"idad", ""},
+			{"es", ""}, {"s", ""}, {"a", ""}, {"o", ""},
+		},
+	},
+	"fr": {
+		StopWords: strings.Fields(`à au aux avec ce ces dans de des du elle en est et il ils je la le les leur mais
+			ne nous ou par pas pour qui que sa se ses son sur un une vous`),
+		Suffixes: [][2]string{
+			{"issement", ""}, {"ations", ""}, {"ation", ""}, {"ement", ""}, {"ment", ""}, {"euses", ""},
+			{"euse", ""}, {"eux", ""}, {"es", ""}, {"s", ""}, {"e", ""},
+		},
+	},
+	"pt": {
+		StopWords: strings.Fields(`a ao aos as com da das de do dos e é em na nas no nos o os ou para pela pelo
+			por que se sem seu sua um uma`),
+		Suffixes: [][2]string{
+			{"amento", ""}, {"imento", ""}, {"ações", ""}, {"ação", ""}, {"mente", ""}, {"idade", ""},
+			{"es", ""}, {"s", ""}, {"a", ""}, {"o", ""},
+		},
+	},
+}
+
+// GetLanguage returns the language for a language code such as "en-us", falling back to the default language.
+func GetLanguage(code string) (string, *Language) {
+	code = strings.ToLower(code)
+	if i := strings.IndexAny(code, "-_"); i >= 0 {
+		code = code[:i]
+	}
+
+	if lang, ok := Languages[code]; ok {
+		return code, lang
+	}
+	return DefaultLanguage, Languages[DefaultLanguage]
+}
+
+func init() {
+	// Build the stop word sets once so tokenizing is safe to use concurrently
+	for _, lang := range Languages {
+		lang.stopSet = make(map[string]bool)
+		for _, word := range lang.StopWords {
+			lang.stopSet[word] = true
+		}
+	}
+}
+
+// Stem strips the first matching suffix from the word, keeping at least minStemLength letters.
+func (l *Language) Stem(word string) string {
+	runes := []rune(word)
+	for _, rule := range l.Suffixes {
+		suffix := []rune(rule[0])
+		if len(runes)-len(suffix) < minStemLength || !strings.HasSuffix(word, rule[0]) {
+			continue
+		}
+		return string(runes[:len(runes)-len(suffix)]) + rule[1]
+	}
+	return word
+}
+
+// Tokenize lowercases the text, splits it into words, drops stop words and stems the rest.
+func (l *Language) Tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	tokens := make([]string, 0, len(words))
+	for _, word := range words {
+		if l.stopSet[word] {
+			continue
+		}
+		tokens = append(tokens, l.Stem(word))
+	}
+	return tokens
+}
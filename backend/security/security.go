@@ -1,7 +1,7 @@
 /*
  * File: security.go
  * File Created: Monday, 12th June 2023 2:03:52 pm
- * Last Modified: Friday, 23rd June 2023 12:28:20 am
+ * Last Modified: Monday, 19th October 2026 1:09:55 pm
  * Author: Akhil Datla
  * Copyright © Akhil Datla 2023
  */
@@ -12,6 +12,8 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"io"
 
 	"golang.org/x/crypto/sha3"
@@ -53,3 +55,33 @@ func DeriveKey(hardwareID string) []byte {
 	// Return the resulting hash as the derived key
 	return hasher.Sum(nil)
 }
+
+// Hash returns the hex-encoded SHA3-256 digest of the data.
+func Hash(data []byte) string {
+	sum := sha3.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Decrypt decrypts data encrypted by Encrypt using the specified key.
+func Decrypt(data []byte, key []byte) ([]byte, error) {
+	// Create a new AES cipher block
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a new Galois Counter Mode (GCM) cipher
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// Split the nonce from the ciphertext
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+
+	// Open the ciphertext using GCM decryption
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
@@ -0,0 +1,94 @@
+/*
+ * Offline search over the index generated into every Learnado package.
+ * Queries are tokenized with the same stop words and suffix rules as the index.
+ */
+(function () {
+  var index = window.LEARNADO_SEARCH_INDEX;
+  var form = document.getElementById("learnado-search");
+  var results = document.getElementById("learnado-search-results");
+  if (!index || !form || !results) {
+    return;
+  }
+
+  var input = form.querySelector("input");
+  var root = document.getElementById("learnado-search-root").href;
+  var stop = {};
+  index.language.stop.forEach(function (word) {
+    stop[word] = true;
+  });
+
+  function stem(word) {
+    var chars = Array.from(word);
+    for (var i = 0; i < index.language.suffixes.length; i++) {
+      var rule = index.language.suffixes[i];
+      var suffixLength = Array.from(rule[0]).length;
+      if (chars.length - suffixLength < index.minStem || !word.endsWith(rule[0])) {
+        continue;
+      }
+      return chars.slice(0, chars.length - suffixLength).join("") + rule[1];
+    }
+    return word;
+  }
+
+  function tokenize(text) {
+    return text.toLowerCase().split(/[^\p{L}\p{N}]+/u).filter(function (word) {
+      return word && !stop[word];
+    }).map(stem);
+  }
+
+  // Rank documents containing every query term by their summed term frequencies
+  function search(query) {
+    var tokens = tokenize(query);
+    var scores = {};
+    var matches = {};
+    tokens.forEach(function (token) {
+      var postings = index.terms[token] || [];
+      for (var i = 0; i < postings.length; i += 2) {
+        scores[postings[i]] = (scores[postings[i]] || 0) + postings[i + 1];
+        matches[postings[i]] = (matches[postings[i]] || 0) + 1;
+      }
+    });
+
+    return Object.keys(scores).filter(function (doc) {
+      return matches[doc] === tokens.length;
+    }).sort(function (a, b) {
+      return scores[b] - scores[a];
+    }).map(function (doc) {
+      return index.docs[doc];
+    });
+  }
+
+  function render(query) {
+    results.innerHTML = "";
+    var found = query.trim() ? search(query) : [];
+    if (query.trim() && found.length === 0) {
+      var empty = document.createElement("p");
+      empty.textContent = "No results for \"" + query + "\"";
+      results.appendChild(empty);
+    }
+
+    found.forEach(function (doc) {
+      var item = document.createElement("li");
+      var link = document.createElement("a");
+      link.href = new URL(doc.u, root).href;
+      link.textContent = doc.t || doc.u;
+      var snippet = document.createElement("p");
+      snippet.textContent = doc.s;
+      item.appendChild(link);
+      item.appendChild(snippet);
+      results.appendChild(item);
+    });
+  }
+
+  form.addEventListener("submit", function (event) {
+    event.preventDefault();
+    render(input.value);
+  });
+
+  // Run the query passed in the address, if any
+  var query = new URLSearchParams(window.location.search).get("q");
+  if (query) {
+    input.value = query;
+    render(query);
+  }
+})();
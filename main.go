@@ -1,7 +1,7 @@
 /*
  * File: main.go
  * File Created: Sunday, 11th June 2023 9:57:15 pm
- * Last Modified: Friday, 23rd June 2023 9:04:22 am
+ * Last Modified: Monday, 19th October 2026 7:00:19 pm
  * Author: Akhil Datla
  * Copyright © Akhil Datla 2023
  */
@@ -10,8 +10,14 @@ package main
 
 import (
 	"flag"
+	"main/backend/courses"
 	"main/backend/dbmanager"
+	"main/backend/licensing"
+	"main/backend/organizations"
 	"main/server"
+	"net"
+	"strings"
+	"time"
 
 	"github.com/pterm/pterm"
 )
@@ -21,8 +27,38 @@ func main() {
 	dbnamePtr := flag.String("dbname", "backendDB.db", "name of the database")
 	portPtr := flag.Int("port", 8080, "port to listen on")
 	logPtr := flag.Bool("log", false, "enable logging")
+	exportPtr := flag.String("export", "", "export packages into the given directory instead of starting the server")
+	devicesPtr := flag.String("devices", "", "comma-separated hardware IDs to export")
+	groupPtr := flag.String("group", "", "device group to export")
+	watchPtr := flag.Int("watch", 30, "seconds between checks of course folders for changes, 0 to disable")
+	blobsPtr := flag.String("blobs", "", "directory of the course revision blob store, next to the database by default")
+	organizationsPtr := flag.String("organizations", "", "directory holding the folders of organizations, next to the database by default")
+	proxiesPtr := flag.String("proxies", "", "comma-separated address ranges of trusted reverse proxies, such as 10.0.0.1/32")
+	symlinksPtr := flag.String("symlinks", courses.SymlinkSkip, "symlink policy for course folders: skip, follow-within-root or error")
 	flag.Parse()
 
+	// Set how symbolic links in course folders are handled
+	switch *symlinksPtr {
+	case courses.SymlinkSkip, courses.SymlinkFollowWithinRoot, courses.SymlinkError:
+		courses.SymlinkPolicy = *symlinksPtr
+	default:
+		pterm.Fatal.Println("invalid symlink policy: " + *symlinksPtr)
+	}
+
+	courses.BlobDir = *blobsPtr
+	organizations.Root = *organizationsPtr
+
+	// Trust the client addresses forwarded by the given reverse proxies
+	if *proxiesPtr != "" {
+		for _, proxy := range strings.Split(*proxiesPtr, ",") {
+			_, ipNet, err := net.ParseCIDR(strings.TrimSpace(proxy))
+			if err != nil {
+				pterm.Fatal.Println("invalid proxy address range: " + proxy)
+			}
+			server.TrustedProxies = append(server.TrustedProxies, ipNet)
+		}
+	}
+
 	// Open the database
 	err := dbmanager.Open(*dbnamePtr)
 	if err != nil {
@@ -31,9 +67,31 @@ func main() {
 
 	defer dbmanager.Close()
 
+	// Export packages and exit if requested
+	if *exportPtr != "" {
+		export(*exportPtr, *devicesPtr, *groupPtr)
+		return
+	}
+
 	// Display the banner
 	banner()
 
+	// Build the search index over all course sources
+	err = courses.IndexAllCourses()
+	if err != nil {
+		pterm.Warning.Println("Could not index courses: " + err.Error())
+	}
+
+	// Watch course folders for changes
+	if *watchPtr > 0 {
+		go courses.WatchCourses(time.Duration(*watchPtr)*time.Second, nil, func(err error) {
+			pterm.Warning.Println("Could not check course: " + err.Error())
+		})
+	}
+
+	// Prune the log of failed registrations in the background
+	go licensing.PruneFailures(licensing.FailurePruneInterval, nil)
+
 	// Start the server
 	server.Start(*portPtr, *logPtr)
 }
@@ -44,3 +102,26 @@ func banner() {
 	pterm.Info.Println("Content Manager Edition")
 	pterm.Info.Println("(c)2023 by Akhil Datla")
 }
+
+// export writes packages for the given hardware IDs or device group into the directory.
+func export(dir, devices, group string) {
+	var index licensing.ExportIndex
+	var err error
+	if group != "" {
+		index, err = licensing.ExportGroupPackages(organizations.Default, group, dir)
+	} else if devices == "" {
+		pterm.Error.Println("either -devices or -group is required")
+		return
+	} else {
+		index, err = licensing.ExportPackages(strings.Split(devices, ","), dir)
+	}
+	if err != nil {
+		pterm.Error.Println(err)
+		return
+	}
+
+	pterm.Success.Printf("Exported %d packages to %s\n", len(index.Devices), dir)
+	for _, hardwareID := range index.Failed {
+		pterm.Warning.Printf("Could not export package for %s\n", hardwareID)
+	}
+}
@@ -1,7 +1,7 @@
 /*
  * File: handlers.go
  * File Created: Sunday, 11th June 2023 9:57:15 pm
- * Last Modified: Friday, 23rd June 2023 12:45:59 am
+ * Last Modified: Monday, 19th October 2026 6:49:28 pm
  * Author: Akhil Datla
  * Copyright © Akhil Datla 2023
  */
@@ -9,12 +9,21 @@
 package server
 
 import (
+	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"main/backend/branding"
 	"main/backend/courses"
+	"main/backend/interop"
 	"main/backend/licensing"
+	"main/backend/organizations"
+	"main/backend/search"
+	"math"
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/labstack/echo/v4"
 )
@@ -30,12 +39,15 @@ func createCourse(c echo.Context) error {
 
 	// Extract the name and filepath from the JSON map
 	name := jsonMap["name"].(string)
-	filepath := jsonMap["filepath"].(string)
+	filepath, err := resolvePath(c, jsonMap["filepath"].(string))
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid filepath")
+	}
 
 	// Create a new course
-	id, err := courses.CreateCourse(name, filepath)
+	id, err := courses.CreateOrganizationCourse(organizationOf(c), name, filepath)
 	if err != nil {
-		return c.String(http.StatusInternalServerError, "Error creating course")
+		return c.String(http.StatusInternalServerError, "Error creating course: "+err.Error())
 	}
 
 	// Return the created course ID
@@ -56,7 +68,7 @@ func getCourse(c echo.Context) error {
 
 	// Retrieve the course
 	course, err := courses.GetCourse(id)
-	if err != nil {
+	if err != nil || course.OrganizationID != organizationOf(c) {
 		return c.String(http.StatusInternalServerError, "Error getting course")
 	}
 
@@ -64,10 +76,10 @@ func getCourse(c echo.Context) error {
 	return c.JSON(http.StatusOK, course)
 }
 
-// getAllCourses retrieves all courses.
+// getAllCourses retrieves all courses of the organization.
 func getAllCourses(c echo.Context) error {
 	// Retrieve all courses
-	courses, err := courses.GetAllCourses()
+	courses, err := courses.GetOrganizationCourses(organizationOf(c))
 	if err != nil {
 		return c.String(http.StatusInternalServerError, "Error getting courses")
 	}
@@ -88,12 +100,19 @@ func updateCourse(c echo.Context) error {
 	// Extract the course details from the JSON map
 	id := jsonMap["id"].(string)
 	name := jsonMap["name"].(string)
-	filepath := jsonMap["filepath"].(string)
+	filepath, err := resolvePath(c, jsonMap["filepath"].(string))
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid filepath")
+	}
+
+	if !ownsCourse(c, id) {
+		return c.String(http.StatusNotFound, "Course not found")
+	}
 
 	// Update the course
 	err = courses.UpdateCourse(id, name, filepath)
 	if err != nil {
-		return c.String(http.StatusInternalServerError, "Error updating course")
+		return c.String(http.StatusInternalServerError, "Error updating course: "+err.Error())
 	}
 
 	return c.String(http.StatusOK, "Course updated")
@@ -111,6 +130,10 @@ func deleteCourse(c echo.Context) error {
 	// Extract the course ID from the JSON map
 	id := jsonMap["id"].(string)
 
+	if !ownsCourse(c, id) {
+		return c.String(http.StatusNotFound, "Course not found")
+	}
+
 	// Delete the course
 	err = courses.DeleteCourse(id)
 	if err != nil {
@@ -120,7 +143,7 @@ func deleteCourse(c echo.Context) error {
 	return c.String(http.StatusOK, "Course deleted")
 }
 
-// generateLicenses generates licenses for a specific course.
+// generateLicenses generates licenses for a specific course or bundle.
 func generateLicenses(c echo.Context) error {
 	// Parse the request body to JSON
 	jsonMap := make(map[string]interface{})
@@ -129,21 +152,296 @@ func generateLicenses(c echo.Context) error {
 		return c.String(http.StatusInternalServerError, "Error parsing request body")
 	}
 
-	// Extract the course ID and number of licenses from the JSON map
-	courseID := jsonMap["courseID"].(string)
+	// Extract the course or bundle ID and number of licenses from the JSON map
+	courseID, _ := jsonMap["courseID"].(string)
+	bundleID, _ := jsonMap["bundleID"].(string)
 	num, err := strconv.Atoi(jsonMap["num"].(string))
 	if err != nil {
 		return c.String(http.StatusInternalServerError, "Error parsing number of licenses")
 	}
 
+	// Extract the optional validity period and number of seats from the JSON map
+	validity, err := parseValidity(jsonMap)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Error parsing validity period")
+	}
+	terms := licensing.Terms{Validity: validity}
+	if seats, _ := jsonMap["seats"].(string); seats != "" {
+		terms.Seats, err = strconv.Atoi(seats)
+		if err != nil {
+			return c.String(http.StatusBadRequest, "Error parsing number of seats")
+		}
+	}
+
+	// Extract the optional key format from the JSON map
+	terms.KeyFormat, err = parseKeyFormat(jsonMap)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Error parsing key length")
+	}
+
+	// Extract the optional batch details from the JSON map
+	batch := licensing.LicenseBatch{CourseID: courseID, BundleID: bundleID, OrganizationID: organizationOf(c)}
+	batch.Label, _ = jsonMap["label"].(string)
+	batch.Customer, _ = jsonMap["customer"].(string)
+	batch.CreatedBy, _ = jsonMap["createdBy"].(string)
+
 	// Generate the licenses
-	licenses, err := licensing.GenerateLicenses(courseID, num)
+	batch, licenses, err := licensing.GenerateBatch(batch, num, terms)
 	if err != nil {
 		return c.String(http.StatusInternalServerError, "Error generating licenses")
 	}
 
-	// Return the generated license keys
-	return c.JSON(http.StatusOK, map[string][]string{"licenseKeys": licenses})
+	// Return the generated license keys and their batch
+	return c.JSON(http.StatusOK, map[string]interface{}{"licenseKeys": licenses, "batchID": batch.ID})
+}
+
+// getAllBatches retrieves all license batches of the organization.
+func getAllBatches(c echo.Context) error {
+	// Retrieve all batches
+	batches, err := licensing.GetOrganizationBatches(organizationOf(c))
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error getting batches")
+	}
+
+	// Return the retrieved batches
+	return c.JSON(http.StatusOK, batches)
+}
+
+// exportBatch downloads the licenses of a batch as CSV.
+func exportBatch(c echo.Context) error {
+	// Parse the request body to JSON
+	jsonMap := make(map[string]interface{})
+	err := json.NewDecoder(c.Request().Body).Decode(&jsonMap)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error parsing request body")
+	}
+
+	// Extract the batch ID from the JSON map
+	id := jsonMap["id"].(string)
+
+	if !ownsBatch(c, id) {
+		return c.String(http.StatusNotFound, "Batch not found")
+	}
+
+	// Export the batch
+	data, err := licensing.ExportBatchCSV(id)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error exporting batch: "+err.Error())
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="licenses.csv"`)
+	return c.Blob(http.StatusOK, "text/csv", data)
+}
+
+// revokeBatch revokes every unused license of a batch.
+func revokeBatch(c echo.Context) error {
+	// Parse the request body to JSON
+	jsonMap := make(map[string]interface{})
+	err := json.NewDecoder(c.Request().Body).Decode(&jsonMap)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error parsing request body")
+	}
+
+	// Extract the batch ID from the JSON map
+	id := jsonMap["id"].(string)
+
+	if !ownsBatch(c, id) {
+		return c.String(http.StatusNotFound, "Batch not found")
+	}
+
+	// Revoke the unused licenses
+	revoked, err := licensing.RevokeUnusedLicenses(id)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error revoking batch: "+err.Error())
+	}
+
+	// Return the number of revoked licenses
+	return c.JSON(http.StatusOK, map[string]int{"revoked": revoked})
+}
+
+// createBundle creates a bundle of courses.
+func createBundle(c echo.Context) error {
+	// Parse the request body to JSON
+	jsonMap := make(map[string]interface{})
+	err := json.NewDecoder(c.Request().Body).Decode(&jsonMap)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error parsing request body")
+	}
+
+	// Extract the name, course IDs and whether the bundle is a learning path from the JSON map
+	name := jsonMap["name"].(string)
+	courseIDs := toStringSlice(jsonMap["courseIDs"])
+	learningPath, _ := jsonMap["learningPath"].(bool)
+
+	// Create the bundle
+	id, err := licensing.CreateBundle(organizationOf(c), name, courseIDs, learningPath)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error creating bundle: "+err.Error())
+	}
+
+	// Return the created bundle ID
+	return c.JSON(http.StatusOK, map[string]string{"bundleID": id})
+}
+
+// getAllBundles retrieves all bundles of the organization.
+func getAllBundles(c echo.Context) error {
+	// Retrieve all bundles
+	bundles, err := licensing.GetOrganizationBundles(organizationOf(c))
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error getting bundles")
+	}
+
+	// Return the retrieved bundles
+	return c.JSON(http.StatusOK, bundles)
+}
+
+// addBundleCourses adds courses to a bundle and entitles the devices that activated its licenses.
+func addBundleCourses(c echo.Context) error {
+	// Parse the request body to JSON
+	jsonMap := make(map[string]interface{})
+	err := json.NewDecoder(c.Request().Body).Decode(&jsonMap)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error parsing request body")
+	}
+
+	// Extract the bundle ID and course IDs from the JSON map
+	id := jsonMap["id"].(string)
+	courseIDs := toStringSlice(jsonMap["courseIDs"])
+
+	if !ownsBundle(c, id) {
+		return c.String(http.StatusNotFound, "Bundle not found")
+	}
+
+	// Add the courses to the bundle
+	entitled, err := licensing.AddBundleCourses(id, courseIDs)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error adding courses to bundle: "+err.Error())
+	}
+
+	// Return the number of created entitlements
+	return c.JSON(http.StatusOK, map[string]int{"entitlements": entitled})
+}
+
+// removeBundleCourse removes a course from a bundle.
+func removeBundleCourse(c echo.Context) error {
+	// Parse the request body to JSON
+	jsonMap := make(map[string]interface{})
+	err := json.NewDecoder(c.Request().Body).Decode(&jsonMap)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error parsing request body")
+	}
+
+	// Extract the bundle ID and course ID from the JSON map
+	id := jsonMap["id"].(string)
+	courseID := jsonMap["courseID"].(string)
+
+	if !ownsBundle(c, id) {
+		return c.String(http.StatusNotFound, "Bundle not found")
+	}
+
+	// Remove the course from the bundle
+	err = licensing.RemoveBundleCourse(id, courseID)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error removing course from bundle: "+err.Error())
+	}
+
+	return c.String(http.StatusOK, "Course removed from bundle")
+}
+
+// deleteBundle deletes a bundle by its ID.
+func deleteBundle(c echo.Context) error {
+	// Parse the request body to JSON
+	jsonMap := make(map[string]interface{})
+	err := json.NewDecoder(c.Request().Body).Decode(&jsonMap)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error parsing request body")
+	}
+
+	// Extract the bundle ID from the JSON map
+	id := jsonMap["id"].(string)
+
+	if !ownsBundle(c, id) {
+		return c.String(http.StatusNotFound, "Bundle not found")
+	}
+
+	// Delete the bundle
+	err = licensing.DeleteBundle(id)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error deleting bundle: "+err.Error())
+	}
+
+	return c.String(http.StatusOK, "Bundle deleted")
+}
+
+// parseValidity parses the optional "expiresAt" RFC 3339 time and "durationDays" number of days of a validity period.
+func parseValidity(jsonMap map[string]interface{}) (licensing.Validity, error) {
+	var validity licensing.Validity
+
+	expiresAt, err := parseOptionalTime(jsonMap["expiresAt"])
+	if err != nil {
+		return validity, err
+	}
+	validity.ExpiresAt = expiresAt
+
+	if days, _ := jsonMap["durationDays"].(string); days != "" {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return validity, err
+		}
+		validity.Duration = time.Duration(n) * 24 * time.Hour
+	}
+
+	return validity, nil
+}
+
+// parseKeyFormat parses the optional "prefix" and "keyLength" of a license key format.
+func parseKeyFormat(jsonMap map[string]interface{}) (licensing.KeyFormat, error) {
+	var format licensing.KeyFormat
+	format.Prefix, _ = jsonMap["prefix"].(string)
+
+	if length, _ := jsonMap["keyLength"].(string); length != "" {
+		n, err := strconv.Atoi(length)
+		if err != nil {
+			return format, err
+		}
+		format.Length = n
+	}
+
+	// A prefix alone keeps the default length
+	if format.Prefix != "" && format.Length == 0 {
+		format.Length = licensing.DefaultKeyFormat.Length
+	}
+
+	return format, nil
+}
+
+// setLicenseKeyFormat sets the format of the license keys generated for a course.
+func setLicenseKeyFormat(c echo.Context) error {
+	// Parse the request body to JSON
+	jsonMap := make(map[string]interface{})
+	err := json.NewDecoder(c.Request().Body).Decode(&jsonMap)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error parsing request body")
+	}
+
+	// Extract the course ID and key format from the JSON map
+	courseID := jsonMap["courseID"].(string)
+	format, err := parseKeyFormat(jsonMap)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Error parsing key length")
+	}
+
+	if !ownsCourse(c, courseID) {
+		return c.String(http.StatusNotFound, "Course not found")
+	}
+
+	// Set the key format
+	err = licensing.SetCourseKeyFormat(courseID, format)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error setting key format: "+err.Error())
+	}
+
+	return c.String(http.StatusOK, "Key format set")
 }
 
 // registerLicense registers a license with a specific license key and hardware ID.
@@ -160,16 +458,45 @@ func registerLicense(c echo.Context) error {
 	hardwareID := jsonMap["hardwareID"].(string)
 
 	// Register the license
-	err = licensing.RegisterLicense(licenseKey, hardwareID)
+	activation, err := licensing.RegisterLicenseFrom(c.RealIP(), licenseKey, hardwareID)
 	if err != nil {
-		return c.String(http.StatusInternalServerError, "Error registering license")
+		if throttled(c, err) {
+			return c.String(http.StatusTooManyRequests, "Error registering license: "+err.Error())
+		}
+		return c.String(http.StatusInternalServerError, "Error registering license: "+err.Error())
 	}
 
-	return c.String(http.StatusOK, "License registered")
+	// Return the number of seats left on the license and the license token
+	return c.JSON(http.StatusOK, activation)
 }
 
-// revokeLicense revokes a license with a specific license key.
-func revokeLicense(c echo.Context) error {
+// activateLicenseOffline activates a license from the request code shown by a device without connectivity.
+func activateLicenseOffline(c echo.Context) error {
+	// Parse the request body to JSON
+	jsonMap := make(map[string]interface{})
+	err := json.NewDecoder(c.Request().Body).Decode(&jsonMap)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error parsing request body")
+	}
+
+	// Extract the request code from the JSON map
+	requestCode := jsonMap["requestCode"].(string)
+
+	// Activate the license
+	activation, err := licensing.ActivateOffline(c.RealIP(), requestCode)
+	if err != nil {
+		if throttled(c, err) {
+			return c.String(http.StatusTooManyRequests, "Error activating license: "+err.Error())
+		}
+		return c.String(http.StatusInternalServerError, "Error activating license: "+err.Error())
+	}
+
+	// Return the activation code for the device
+	return c.JSON(http.StatusOK, activation)
+}
+
+// getLicenseSeats retrieves the seats of a license and the hardware IDs that took them.
+func getLicenseSeats(c echo.Context) error {
 	// Parse the request body to JSON
 	jsonMap := make(map[string]interface{})
 	err := json.NewDecoder(c.Request().Body).Decode(&jsonMap)
@@ -180,17 +507,106 @@ func revokeLicense(c echo.Context) error {
 	// Extract the license key from the JSON map
 	licenseKey := jsonMap["licenseKey"].(string)
 
-	// Revoke the license
-	err = licensing.RevokeLicense(licenseKey)
+	if !ownsLicense(c, licenseKey) {
+		return c.String(http.StatusNotFound, "License not found")
+	}
+
+	// Retrieve the seats of the license
+	seats, err := licensing.GetLicenseSeats(licenseKey)
 	if err != nil {
-		return c.String(http.StatusInternalServerError, "Error revoking license")
+		return c.String(http.StatusInternalServerError, "Error getting license seats")
 	}
 
-	return c.String(http.StatusOK, "License revoked")
+	// Return the seats of the license
+	return c.JSON(http.StatusOK, seats)
 }
 
-// downloadCourses downloads courses for a specific hardware ID.
-func downloadCourses(c echo.Context) error {
+// getLicense retrieves the status, activations and history of a license by its key.
+func getLicense(c echo.Context) error {
+	// Extract the license key from the path
+	licenseKey := c.Param("key")
+
+	if !ownsLicense(c, licenseKey) {
+		return c.String(http.StatusNotFound, "License not found")
+	}
+
+	// Retrieve the lifecycle of the license
+	lifecycle, err := licensing.GetLifecycle(licenseKey)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error getting license: "+err.Error())
+	}
+
+	// Return the lifecycle of the license
+	return c.JSON(http.StatusOK, lifecycle)
+}
+
+// getRegistrationFailures retrieves the failed license registrations of the organization of the request.
+// Failures with license keys that belong to no license are visible to server admins.
+func getRegistrationFailures(c echo.Context) error {
+	// Retrieve the failed registrations
+	failures, err := licensing.GetRegistrationFailures(organizationOf(c))
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error getting registration failures")
+	}
+
+	// Return the failed registrations
+	return c.JSON(http.StatusOK, failures)
+}
+
+// unlockRegistrations lifts the registration lockout of an IP address or a hardware ID.
+func unlockRegistrations(c echo.Context) error {
+	if !isServerAdmin(c) {
+		return c.String(http.StatusForbidden, "Only server admins can lift lockouts")
+	}
+
+	// Parse the request body to JSON
+	jsonMap := make(map[string]interface{})
+	err := json.NewDecoder(c.Request().Body).Decode(&jsonMap)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error parsing request body")
+	}
+
+	// Extract the IP address and hardware ID from the JSON map
+	ip, _ := jsonMap["ip"].(string)
+	hardwareID, _ := jsonMap["hardwareID"].(string)
+
+	// Forget their failed registrations
+	licensing.Unlock(ip, hardwareID)
+
+	return c.String(http.StatusOK, "Lockout lifted")
+}
+
+// setLicenseSeats raises or lowers the number of seats of a license.
+func setLicenseSeats(c echo.Context) error {
+	// Parse the request body to JSON
+	jsonMap := make(map[string]interface{})
+	err := json.NewDecoder(c.Request().Body).Decode(&jsonMap)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error parsing request body")
+	}
+
+	// Extract the license key and number of seats from the JSON map
+	licenseKey := jsonMap["licenseKey"].(string)
+	seats, err := strconv.Atoi(jsonMap["seats"].(string))
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Error parsing number of seats")
+	}
+
+	if !ownsLicense(c, licenseKey) {
+		return c.String(http.StatusNotFound, "License not found")
+	}
+
+	// Set the number of seats
+	err = licensing.SetLicenseSeats(licenseKey, seats)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error setting license seats: "+err.Error())
+	}
+
+	return c.String(http.StatusOK, "License seats set")
+}
+
+// getEntitlements retrieves the entitlements of a specific hardware ID.
+func getEntitlements(c echo.Context) error {
 	// Parse the request body to JSON
 	jsonMap := make(map[string]interface{})
 	err := json.NewDecoder(c.Request().Body).Decode(&jsonMap)
@@ -201,13 +617,966 @@ func downloadCourses(c echo.Context) error {
 	// Extract the hardware ID from the JSON map
 	hardwareID := jsonMap["hardwareID"].(string)
 
-	// Download the courses for the hardware ID
-	file, err := licensing.DownloadCourses(hardwareID)
+	// Retrieve the entitlements
+	entitlements, err := licensing.GetEntitlements(organizationOf(c), hardwareID)
 	if err != nil {
-		return c.String(http.StatusInternalServerError, "Error downloading courses")
+		return c.String(http.StatusInternalServerError, "Error getting entitlements")
 	}
 
-	defer os.Remove(file)
+	// Return the retrieved entitlements
+	return c.JSON(http.StatusOK, entitlements)
+}
+
+// renewEntitlements extends the time-limited entitlements of a hardware ID, a course, or both.
+func renewEntitlements(c echo.Context) error {
+	// Parse the request body to JSON
+	jsonMap := make(map[string]interface{})
+	err := json.NewDecoder(c.Request().Body).Decode(&jsonMap)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error parsing request body")
+	}
+
+	// Extract the hardware ID, course ID and renewal period from the JSON map
+	hardwareID, _ := jsonMap["hardwareID"].(string)
+	courseID, _ := jsonMap["courseID"].(string)
+	validity, err := parseValidity(jsonMap)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Error parsing renewal period")
+	}
+
+	// Renew the entitlements
+	renewed, err := licensing.RenewEntitlements(organizationOf(c), hardwareID, courseID, validity)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error renewing entitlements: "+err.Error())
+	}
+
+	// Return the number of renewed entitlements
+	return c.JSON(http.StatusOK, map[string]int{"renewed": renewed})
+}
+
+// deleteEntitlement deactivates an entitlement by its ID, returning its seat to the license.
+func deleteEntitlement(c echo.Context) error {
+	// Parse the request body to JSON
+	jsonMap := make(map[string]interface{})
+	err := json.NewDecoder(c.Request().Body).Decode(&jsonMap)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error parsing request body")
+	}
+
+	// Extract the ID from the JSON map
+	id := jsonMap["id"].(string)
+
+	// Deactivate the entitlement
+	err = licensing.DeactivateEntitlement(organizationOf(c), id)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error deactivating entitlement")
+	}
+
+	return c.String(http.StatusOK, "Entitlement deactivated")
+}
+
+// deactivateLicense lets a device give back the seat it took of a license.
+func deactivateLicense(c echo.Context) error {
+	// Parse the request body to JSON
+	jsonMap := make(map[string]interface{})
+	err := json.NewDecoder(c.Request().Body).Decode(&jsonMap)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error parsing request body")
+	}
+
+	// Extract the license key and hardware ID from the JSON map
+	licenseKey := jsonMap["licenseKey"].(string)
+	hardwareID := jsonMap["hardwareID"].(string)
+
+	// Deactivate the license on the device
+	err = licensing.DeactivateDevice(licenseKey, hardwareID)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error deactivating license")
+	}
+
+	return c.String(http.StatusOK, "License deactivated")
+}
+
+// transferEntitlements moves the entitlements of one hardware ID to another.
+func transferEntitlements(c echo.Context) error {
+	// Parse the request body to JSON
+	jsonMap := make(map[string]interface{})
+	err := json.NewDecoder(c.Request().Body).Decode(&jsonMap)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error parsing request body")
+	}
+
+	// Extract the old and new hardware IDs from the JSON map
+	fromHardwareID := jsonMap["fromHardwareID"].(string)
+	toHardwareID := jsonMap["toHardwareID"].(string)
+
+	// Transfer the entitlements
+	transfer, err := licensing.TransferEntitlements(organizationOf(c), fromHardwareID, toHardwareID)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error transferring entitlements: "+err.Error())
+	}
+
+	// Return the transfer record
+	return c.JSON(http.StatusOK, transfer)
+}
+
+// revokeEntitlements revokes the activated entitlements of a license, a course, a hardware ID, or a combination of them.
+func revokeEntitlements(c echo.Context) error {
+	// Parse the request body to JSON
+	jsonMap := make(map[string]interface{})
+	err := json.NewDecoder(c.Request().Body).Decode(&jsonMap)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error parsing request body")
+	}
+
+	// Extract the license key, course ID and hardware ID from the JSON map
+	licenseKey, _ := jsonMap["licenseKey"].(string)
+	courseID, _ := jsonMap["courseID"].(string)
+	hardwareID, _ := jsonMap["hardwareID"].(string)
+
+	// Revoke the entitlements
+	revoked, err := licensing.RevokeEntitlements(organizationOf(c), licenseKey, courseID, hardwareID)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error revoking entitlements: "+err.Error())
+	}
+
+	// Return the number of revoked entitlements
+	return c.JSON(http.StatusOK, map[string]int{"revoked": revoked})
+}
+
+// getRevokedEntitlements retrieves all revoked entitlements of the organization.
+func getRevokedEntitlements(c echo.Context) error {
+	// Retrieve the revoked entitlements
+	entitlements, err := licensing.GetRevokedEntitlements(organizationOf(c))
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error getting revoked entitlements")
+	}
+
+	// Return the retrieved entitlements
+	return c.JSON(http.StatusOK, entitlements)
+}
+
+// getPublicKey returns the base64-encoded public key that devices verify signed messages with.
+func getPublicKey(c echo.Context) error {
+	// Retrieve the public key
+	publicKey, err := licensing.PublicKey()
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error getting public key")
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"publicKey": base64.StdEncoding.EncodeToString(publicKey)})
+}
+
+// revokeLicense revokes a license with a specific license key.
+func revokeLicense(c echo.Context) error {
+	// Parse the request body to JSON
+	jsonMap := make(map[string]interface{})
+	err := json.NewDecoder(c.Request().Body).Decode(&jsonMap)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error parsing request body")
+	}
+
+	// Extract the license key from the JSON map
+	licenseKey := jsonMap["licenseKey"].(string)
+
+	if !ownsLicense(c, licenseKey) {
+		return c.String(http.StatusNotFound, "License not found")
+	}
+
+	// Revoke the license
+	err = licensing.RevokeLicense(licenseKey)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error revoking license")
+	}
+
+	return c.String(http.StatusOK, "License revoked")
+}
+
+// downloadCourses downloads courses for a specific hardware ID.
+func downloadCourses(c echo.Context) error {
+	// Parse the request body to JSON
+	jsonMap := make(map[string]interface{})
+	err := json.NewDecoder(c.Request().Body).Decode(&jsonMap)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error parsing request body")
+	}
+
+	// Extract the hardware ID from the JSON map
+	hardwareID := jsonMap["hardwareID"].(string)
+
+	// Download the courses for the hardware ID
+	download, err := licensing.DownloadCourses(hardwareID)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error downloading courses")
+	}
+
+	defer os.Remove(download.Package)
+
+	// Report embargoed courses and excluded files alongside the package
+	c.Response().Header().Set("X-Embargoed-Courses", strings.Join(download.Embargoed, ","))
+	c.Response().Header().Set("X-Expired-Courses", strings.Join(download.Expired, ","))
+	c.Response().Header().Set("X-Revoked-Courses", strings.Join(download.Revoked, ","))
+	c.Response().Header().Set("X-Revocation-List", download.Revocations)
+	c.Response().Header().Set("X-License-Tokens", strings.Join(download.Tokens, ","))
+	c.Response().Header().Set("X-Excluded-Files", strconv.Itoa(len(download.Report.Excluded)))
+
+	return c.File(download.Package)
+}
+
+// downloadCourseVolumes splits the courses of the organization for a specific hardware ID into fixed-size volumes.
+func downloadCourseVolumes(c echo.Context) error {
+	// Parse the request body to JSON
+	jsonMap := make(map[string]interface{})
+	err := json.NewDecoder(c.Request().Body).Decode(&jsonMap)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error parsing request body")
+	}
+
+	// Extract the hardware ID, output directory and volume size from the JSON map
+	hardwareID := jsonMap["hardwareID"].(string)
+	outputDir, err := resolvePath(c, jsonMap["outputDir"].(string))
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid output directory")
+	}
+	volumeSize, err := strconv.ParseInt(jsonMap["volumeSize"].(string), 10, 64)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error parsing volume size")
+	}
+
+	// Build and split the package for the hardware ID
+	manifest, err := licensing.DownloadCourseVolumes(organizationOf(c), hardwareID, outputDir, volumeSize)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error splitting courses into volumes")
+	}
+
+	// Return the volume manifest
+	return c.JSON(http.StatusOK, manifest)
+}
+
+// createDeviceGroup handles the creation of a new device group.
+func createDeviceGroup(c echo.Context) error {
+	// Parse the request body to JSON
+	jsonMap := make(map[string]interface{})
+	err := json.NewDecoder(c.Request().Body).Decode(&jsonMap)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error parsing request body")
+	}
+
+	// Extract the name and hardware IDs from the JSON map
+	name := jsonMap["name"].(string)
+	hardwareIDs := toStringSlice(jsonMap["hardwareIDs"])
+
+	// Create the device group
+	id, err := licensing.CreateDeviceGroup(organizationOf(c), name, hardwareIDs)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error creating device group")
+	}
+
+	// Return the created device group ID
+	return c.JSON(http.StatusOK, map[string]string{"groupID": id})
+}
+
+// getStaleDeliveries retrieves the devices whose packages contain courses that changed since.
+func getStaleDeliveries(c echo.Context) error {
+	// Retrieve the stale deliveries
+	deliveries, err := licensing.GetStaleDeliveries(organizationOf(c))
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error getting stale packages")
+	}
+
+	// Return the retrieved deliveries
+	return c.JSON(http.StatusOK, deliveries)
+}
+
+// getAllDeviceGroups retrieves all device groups of the organization.
+func getAllDeviceGroups(c echo.Context) error {
+	// Retrieve all device groups
+	groups, err := licensing.GetOrganizationDeviceGroups(organizationOf(c))
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error getting device groups")
+	}
+
+	// Return the retrieved device groups
+	return c.JSON(http.StatusOK, groups)
+}
+
+// deleteDeviceGroup deletes a device group by its ID.
+func deleteDeviceGroup(c echo.Context) error {
+	// Parse the request body to JSON
+	jsonMap := make(map[string]interface{})
+	err := json.NewDecoder(c.Request().Body).Decode(&jsonMap)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error parsing request body")
+	}
+
+	// Extract the device group ID from the JSON map
+	id := jsonMap["id"].(string)
+
+	// Delete the device group
+	err = licensing.DeleteDeviceGroup(organizationOf(c), id)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error deleting device group")
+	}
+
+	return c.String(http.StatusOK, "Device group deleted")
+}
+
+// exportPackages writes packages of the courses of the organization for a list of hardware IDs or
+// a device group into a directory.
+func exportPackages(c echo.Context) error {
+	// Parse the request body to JSON
+	jsonMap := make(map[string]interface{})
+	err := json.NewDecoder(c.Request().Body).Decode(&jsonMap)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error parsing request body")
+	}
+
+	// Extract the output directory from the JSON map
+	outputDir, err := resolvePath(c, jsonMap["outputDir"].(string))
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid output directory")
+	}
+
+	// Export either the named device group or the listed hardware IDs
+	var index licensing.ExportIndex
+	if group, ok := jsonMap["group"].(string); ok && group != "" {
+		if !ownsDeviceGroup(c, group) {
+			return c.String(http.StatusNotFound, "Device group not found")
+		}
+		index, err = licensing.ExportGroupPackages(organizationOf(c), group, outputDir)
+	} else {
+		index, err = licensing.ExportOrganizationPackages(organizationOf(c), toStringSlice(jsonMap["hardwareIDs"]), outputDir)
+	}
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error exporting packages")
+	}
+
+	// Return the export index
+	return c.JSON(http.StatusOK, index)
+}
+
+// toStringSlice converts a decoded JSON array to a slice of strings, skipping non-string values.
+func toStringSlice(value interface{}) []string {
+	values := make([]string, 0)
+	items, _ := value.([]interface{})
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			values = append(values, s)
+		}
+	}
+	return values
+}
+
+// setBranding sets the branding of a course, or the branding of the organization if no course ID is given.
+func setBranding(c echo.Context) error {
+	// Parse the request body to JSON
+	jsonMap := make(map[string]interface{})
+	err := json.NewDecoder(c.Request().Body).Decode(&jsonMap)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error parsing request body")
+	}
+
+	// Extract the branding values from the JSON map
+	courseID, _ := jsonMap["courseID"].(string)
+	b := branding.Branding{}
+	if logo, _ := jsonMap["logo"].(string); logo != "" {
+		b.Logo, err = resolvePath(c, logo)
+		if err != nil {
+			return c.String(http.StatusBadRequest, "Invalid logo filepath")
+		}
+	}
+	b.ThemeVariant, _ = jsonMap["themeVariant"].(string)
+	b.Title, _ = jsonMap["title"].(string)
+	b.CustomCSS, _ = jsonMap["customCSS"].(string)
+	b.FooterText, _ = jsonMap["footerText"].(string)
+
+	// Save the branding for the course or for the organization
+	if courseID != "" {
+		if !ownsCourse(c, courseID) {
+			return c.String(http.StatusNotFound, "Course not found")
+		}
+		err = courses.SetCourseBranding(courseID, b)
+	} else {
+		err = branding.SetBranding(organizations.BrandingOwner(organizationOf(c)), b)
+	}
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Error setting branding: "+err.Error())
+	}
+
+	return c.String(http.StatusOK, "Branding set")
+}
+
+// getBranding retrieves the branding of a course, or the branding of the organization if no course ID is given.
+func getBranding(c echo.Context) error {
+	// Parse the request body to JSON
+	jsonMap := make(map[string]interface{})
+	err := json.NewDecoder(c.Request().Body).Decode(&jsonMap)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error parsing request body")
+	}
+
+	// Extract the course ID from the JSON map
+	ownerID, _ := jsonMap["courseID"].(string)
+	if ownerID == "" {
+		ownerID = organizations.BrandingOwner(organizationOf(c))
+	} else if !ownsCourse(c, ownerID) {
+		return c.String(http.StatusNotFound, "Course not found")
+	}
+
+	// Retrieve the branding
+	b, err := branding.GetBranding(ownerID)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error getting branding")
+	}
+
+	// Return the retrieved branding
+	return c.JSON(http.StatusOK, b)
+}
+
+// deleteBranding removes the branding of a course, or the branding of the organization if no course ID is given.
+func deleteBranding(c echo.Context) error {
+	// Parse the request body to JSON
+	jsonMap := make(map[string]interface{})
+	err := json.NewDecoder(c.Request().Body).Decode(&jsonMap)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error parsing request body")
+	}
+
+	// Extract the course ID from the JSON map
+	ownerID, _ := jsonMap["courseID"].(string)
+	if ownerID == "" {
+		ownerID = organizations.BrandingOwner(organizationOf(c))
+	} else if !ownsCourse(c, ownerID) {
+		return c.String(http.StatusNotFound, "Course not found")
+	}
+
+	// Delete the branding
+	err = branding.DeleteBranding(ownerID)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error deleting branding")
+	}
+
+	return c.String(http.StatusOK, "Branding deleted")
+}
+
+// scheduleCourse sets the publication window of a course.
+func scheduleCourse(c echo.Context) error {
+	// Parse the request body to JSON
+	jsonMap := make(map[string]interface{})
+	err := json.NewDecoder(c.Request().Body).Decode(&jsonMap)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error parsing request body")
+	}
+
+	// Extract the course ID and publication window from the JSON map
+	id := jsonMap["id"].(string)
+	publishAt, err := parseOptionalTime(jsonMap["publishAt"])
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Error parsing publish time")
+	}
+	unpublishAt, err := parseOptionalTime(jsonMap["unpublishAt"])
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Error parsing unpublish time")
+	}
+
+	if !ownsCourse(c, id) {
+		return c.String(http.StatusNotFound, "Course not found")
+	}
+
+	// Set the publication window
+	err = courses.SetPublicationWindow(id, publishAt, unpublishAt)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error scheduling course: "+err.Error())
+	}
+
+	return c.String(http.StatusOK, "Course scheduled")
+}
+
+// parseOptionalTime parses an RFC 3339 time from a decoded JSON value, returning the zero time if it is missing or empty.
+func parseOptionalTime(value interface{}) (time.Time, error) {
+	s, _ := value.(string)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+// scanCourse lists the files of a course that are left out of builds.
+func scanCourse(c echo.Context) error {
+	// Parse the request body to JSON
+	jsonMap := make(map[string]interface{})
+	err := json.NewDecoder(c.Request().Body).Decode(&jsonMap)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error parsing request body")
+	}
+
+	// Extract the course ID from the JSON map
+	id := jsonMap["id"].(string)
+
+	if !ownsCourse(c, id) {
+		return c.String(http.StatusNotFound, "Course not found")
+	}
+
+	// Scan the course folder
+	excluded, err := courses.ScanCourse(id)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error scanning course: "+err.Error())
+	}
+
+	// Return the excluded files
+	return c.JSON(http.StatusOK, map[string][]string{"excluded": excluded})
+}
+
+// getCourseRevisions retrieves the stored revisions of a course.
+func getCourseRevisions(c echo.Context) error {
+	// Parse the request body to JSON
+	jsonMap := make(map[string]interface{})
+	err := json.NewDecoder(c.Request().Body).Decode(&jsonMap)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error parsing request body")
+	}
+
+	// Extract the course ID from the JSON map
+	id := jsonMap["id"].(string)
+
+	if !ownsCourse(c, id) {
+		return c.String(http.StatusNotFound, "Course not found")
+	}
+
+	// Retrieve the revisions
+	revisions, err := courses.GetCourseRevisions(id)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error getting course revisions")
+	}
+
+	// Return the retrieved revisions
+	return c.JSON(http.StatusOK, revisions)
+}
+
+// restoreCourseRevision writes the files of a course revision into a directory.
+func restoreCourseRevision(c echo.Context) error {
+	// Parse the request body to JSON
+	jsonMap := make(map[string]interface{})
+	err := json.NewDecoder(c.Request().Body).Decode(&jsonMap)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error parsing request body")
+	}
+
+	// Extract the revision ID and output directory from the JSON map
+	revisionID := jsonMap["revisionID"].(string)
+	outputDir, err := resolvePath(c, jsonMap["outputDir"].(string))
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid output directory")
+	}
+
+	revision, err := courses.GetRevision(revisionID)
+	if err != nil || !ownsCourse(c, revision.CourseID) {
+		return c.String(http.StatusNotFound, "Course revision not found")
+	}
+
+	// Restore the revision
+	err = courses.RestoreRevision(revisionID, outputDir)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error restoring course revision")
+	}
+
+	return c.String(http.StatusOK, "Course revision restored")
+}
+
+// importCourse imports a SCORM or IMS Common Cartridge package as a new course.
+func importCourse(c echo.Context) error {
+	// Parse the request body to JSON
+	jsonMap := make(map[string]interface{})
+	err := json.NewDecoder(c.Request().Body).Decode(&jsonMap)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error parsing request body")
+	}
+
+	// Extract the package path, course name and output directory from the JSON map
+	zipPath, err := resolvePath(c, jsonMap["zipPath"].(string))
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid package path")
+	}
+	outputDir, err := resolvePath(c, jsonMap["outputDir"].(string))
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid output directory")
+	}
+	name, _ := jsonMap["name"].(string)
+
+	// Import the package
+	report, err := interop.ImportPackage(organizationOf(c), zipPath, name, outputDir)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error importing course: "+err.Error())
+	}
+
+	// Return the import report
+	return c.JSON(http.StatusOK, report)
+}
+
+// exportCourseSCORM exports a course as a SCORM 1.2 package.
+func exportCourseSCORM(c echo.Context) error {
+	// Parse the request body to JSON
+	jsonMap := make(map[string]interface{})
+	err := json.NewDecoder(c.Request().Body).Decode(&jsonMap)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error parsing request body")
+	}
+
+	// Extract the course ID from the JSON map
+	id := jsonMap["id"].(string)
+
+	if !ownsCourse(c, id) {
+		return c.String(http.StatusNotFound, "Course not found")
+	}
+
+	// Export the course
+	file, err := interop.ExportSCORM(id)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error exporting course: "+err.Error())
+	}
+
+	defer os.Remove(file)
+
+	return c.Attachment(file, "course-scorm.zip")
+}
+
+// searchCourses searches the sources of all courses of the organization for a query.
+func searchCourses(c echo.Context) error {
+	// Parse the request body to JSON
+	jsonMap := make(map[string]interface{})
+	err := json.NewDecoder(c.Request().Body).Decode(&jsonMap)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error parsing request body")
+	}
+
+	// Extract the query and optional result limit from the JSON map
+	query := jsonMap["query"].(string)
+	limit := 50
+	if limitString, ok := jsonMap["limit"].(string); ok {
+		limit, err = strconv.Atoi(limitString)
+		if err != nil {
+			return c.String(http.StatusInternalServerError, "Error parsing limit")
+		}
+	}
+
+	// Keep the matches in courses of the organization
+	hits := make([]search.Hit, 0)
+	for _, hit := range search.Search(query, 0) {
+		if limit > 0 && len(hits) == limit {
+			break
+		}
+		if ownsCourse(c, hit.CourseID) {
+			hits = append(hits, hit)
+		}
+	}
+
+	// Return the matching pages and assets
+	return c.JSON(http.StatusOK, hits)
+}
+
+// setCourseGitSource makes a course build from a branch, tag or commit of the git repository at its filepath.
+func setCourseGitSource(c echo.Context) error {
+	// Parse the request body to JSON
+	jsonMap := make(map[string]interface{})
+	err := json.NewDecoder(c.Request().Body).Decode(&jsonMap)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error parsing request body")
+	}
+
+	// Extract the course ID and git ref from the JSON map
+	id := jsonMap["id"].(string)
+	ref, _ := jsonMap["ref"].(string)
+
+	if !ownsCourse(c, id) {
+		return c.String(http.StatusNotFound, "Course not found")
+	}
+
+	// Set the git source
+	err = courses.SetGitSource(id, ref)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error setting git source: "+err.Error())
+	}
+
+	return c.String(http.StatusOK, "Course git source set")
+}
+
+// throttled reports whether the error refuses a registration of a client that failed too often,
+// telling the client when to retry.
+func throttled(c echo.Context, err error) bool {
+	var throttleErr *licensing.ThrottleError
+	if !errors.As(err, &throttleErr) {
+		return false
+	}
+
+	seconds := int(math.Ceil(throttleErr.RetryAfter.Seconds()))
+	c.Response().Header().Set("Retry-After", strconv.Itoa(seconds))
+	return true
+}
+
+// organizationOf returns the ID of the organization the request acts for.
+func organizationOf(c echo.Context) string {
+	organizationID, _ := c.Get(organizationKey).(string)
+	return organizationID
+}
+
+// isServerAdmin reports whether the request acts for the default organization, which manages the others.
+func isServerAdmin(c echo.Context) bool {
+	return organizationOf(c) == organizations.Default
+}
+
+// resolvePath returns the server path of a path given in the request. Admins of organizations other
+// than the default one are confined to the folder of their organization.
+func resolvePath(c echo.Context, path string) (string, error) {
+	return organizations.ResolvePath(organizationOf(c), path)
+}
+
+// resolveHomepage returns the server path of the optional homepage given in the request, resolved
+// like every other path of the requesting admin.
+func resolveHomepage(c echo.Context, jsonMap map[string]interface{}) (string, error) {
+	homepage, _ := jsonMap["homepage"].(string)
+	if homepage == "" {
+		return "", nil
+	}
+	return resolvePath(c, homepage)
+}
+
+// ownsCourse reports whether the course with the given ID belongs to the organization of the request.
+func ownsCourse(c echo.Context, id string) bool {
+	course, err := courses.GetCourse(id)
+	return err == nil && course.OrganizationID == organizationOf(c)
+}
+
+// ownsLicense reports whether the license with the given key belongs to the organization of the request.
+func ownsLicense(c echo.Context, key string) bool {
+	license, err := licensing.GetLicense(key)
+	return err == nil && license.OrganizationID == organizationOf(c)
+}
+
+// ownsBatch reports whether the license batch with the given ID belongs to the organization of the request.
+func ownsBatch(c echo.Context, id string) bool {
+	batch, err := licensing.GetBatch(id)
+	return err == nil && batch.OrganizationID == organizationOf(c)
+}
+
+// ownsBundle reports whether the bundle with the given ID belongs to the organization of the request.
+func ownsBundle(c echo.Context, id string) bool {
+	bundle, err := licensing.GetBundle(id)
+	return err == nil && bundle.OrganizationID == organizationOf(c)
+}
+
+// ownsDeviceGroup reports whether the named device group belongs to the organization of the request.
+func ownsDeviceGroup(c echo.Context, name string) bool {
+	_, err := licensing.GetDeviceGroup(organizationOf(c), name)
+	return err == nil
+}
+
+// createOrganization handles the creation of a new organization.
+func createOrganization(c echo.Context) error {
+	if !isServerAdmin(c) {
+		return c.String(http.StatusForbidden, "Only server admins can manage organizations")
+	}
+
+	// Parse the request body to JSON
+	jsonMap := make(map[string]interface{})
+	err := json.NewDecoder(c.Request().Body).Decode(&jsonMap)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error parsing request body")
+	}
+
+	// Extract the name and optional homepage from the JSON map
+	name := jsonMap["name"].(string)
+	homepage, err := resolveHomepage(c, jsonMap)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid homepage filepath")
+	}
+
+	// Create the organization
+	id, err := organizations.CreateOrganization(name, homepage)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error creating organization: "+err.Error())
+	}
+
+	// Return the created organization ID
+	return c.JSON(http.StatusOK, map[string]string{"organizationID": id})
+}
+
+// getAllOrganizations retrieves all organizations.
+func getAllOrganizations(c echo.Context) error {
+	if !isServerAdmin(c) {
+		return c.String(http.StatusForbidden, "Only server admins can manage organizations")
+	}
+
+	// Retrieve all organizations
+	organizations, err := organizations.GetAllOrganizations()
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error getting organizations")
+	}
+
+	// Return the retrieved organizations
+	return c.JSON(http.StatusOK, organizations)
+}
+
+// getOrganization retrieves the organization of the request. Server admins may ask for any organization.
+func getOrganization(c echo.Context) error {
+	// Parse the request body to JSON
+	jsonMap := make(map[string]interface{})
+	err := json.NewDecoder(c.Request().Body).Decode(&jsonMap)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error parsing request body")
+	}
+
+	// Extract the organization ID from the JSON map
+	id := organizationOf(c)
+	if isServerAdmin(c) {
+		id, _ = jsonMap["id"].(string)
+	}
+
+	// Retrieve the organization
+	organization, err := organizations.GetOrganization(id)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error getting organization: "+err.Error())
+	}
+
+	// Return the retrieved organization
+	return c.JSON(http.StatusOK, organization)
+}
+
+// updateOrganization updates the name and homepage of the organization of the request. Server
+// admins may update any organization.
+func updateOrganization(c echo.Context) error {
+	// Parse the request body to JSON
+	jsonMap := make(map[string]interface{})
+	err := json.NewDecoder(c.Request().Body).Decode(&jsonMap)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error parsing request body")
+	}
+
+	// Extract the organization details from the JSON map
+	id := organizationOf(c)
+	if isServerAdmin(c) {
+		id, _ = jsonMap["id"].(string)
+	}
+	name, _ := jsonMap["name"].(string)
+	homepage, err := resolveHomepage(c, jsonMap)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid homepage filepath")
+	}
+
+	// Update the organization
+	err = organizations.UpdateOrganization(id, name, homepage)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error updating organization: "+err.Error())
+	}
+
+	return c.String(http.StatusOK, "Organization updated")
+}
+
+// deleteOrganization deletes an organization without courses by its ID.
+func deleteOrganization(c echo.Context) error {
+	if !isServerAdmin(c) {
+		return c.String(http.StatusForbidden, "Only server admins can manage organizations")
+	}
+
+	// Parse the request body to JSON
+	jsonMap := make(map[string]interface{})
+	err := json.NewDecoder(c.Request().Body).Decode(&jsonMap)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error parsing request body")
+	}
+
+	// Extract the organization ID from the JSON map
+	id := jsonMap["id"].(string)
+
+	// Organizations keep their courses, licenses and entitlements until their courses are deleted
+	owned, err := courses.GetOrganizationCourses(id)
+	if err != nil || len(owned) > 0 {
+		return c.String(http.StatusConflict, "Organization has courses")
+	}
+
+	// Delete the organization
+	err = organizations.DeleteOrganization(id)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error deleting organization: "+err.Error())
+	}
+
+	return c.String(http.StatusOK, "Organization deleted")
+}
+
+// createAdmin creates an admin of the organization of the request and returns its admin key.
+// Server admins may create admins of any organization.
+func createAdmin(c echo.Context) error {
+	// Parse the request body to JSON
+	jsonMap := make(map[string]interface{})
+	err := json.NewDecoder(c.Request().Body).Decode(&jsonMap)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error parsing request body")
+	}
+
+	// Extract the name and organization ID from the JSON map
+	name := jsonMap["name"].(string)
+	organizationID := organizationOf(c)
+	if isServerAdmin(c) {
+		organizationID, _ = jsonMap["organizationID"].(string)
+	}
+
+	// Create the admin
+	admin, key, err := organizations.CreateAdmin(organizationID, name)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error creating admin: "+err.Error())
+	}
+
+	// Return the created admin ID and its admin key, which cannot be retrieved again
+	return c.JSON(http.StatusOK, map[string]string{"adminID": admin.ID, "adminKey": key})
+}
+
+// getAdmins retrieves the admins of the organization of the request. Server admins may ask for
+// the admins of any organization.
+func getAdmins(c echo.Context) error {
+	// Parse the request body to JSON
+	jsonMap := make(map[string]interface{})
+	err := json.NewDecoder(c.Request().Body).Decode(&jsonMap)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error parsing request body")
+	}
+
+	// Extract the organization ID from the JSON map
+	organizationID := organizationOf(c)
+	if isServerAdmin(c) {
+		organizationID, _ = jsonMap["organizationID"].(string)
+	}
+
+	// Retrieve the admins
+	admins, err := organizations.GetAdmins(organizationID)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error getting admins")
+	}
+
+	// Return the retrieved admins
+	return c.JSON(http.StatusOK, admins)
+}
+
+// deleteAdmin deletes an admin of the organization of the request by its ID.
+func deleteAdmin(c echo.Context) error {
+	// Parse the request body to JSON
+	jsonMap := make(map[string]interface{})
+	err := json.NewDecoder(c.Request().Body).Decode(&jsonMap)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error parsing request body")
+	}
+
+	// Extract the admin ID from the JSON map
+	id := jsonMap["id"].(string)
+
+	admin, err := organizations.GetAdmin(id)
+	if err != nil || (!isServerAdmin(c) && admin.OrganizationID != organizationOf(c)) {
+		return c.String(http.StatusNotFound, "Admin not found")
+	}
+
+	// Delete the admin
+	err = organizations.DeleteAdmin(id)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "Error deleting admin: "+err.Error())
+	}
 
-	return c.File(file)
+	return c.String(http.StatusOK, "Admin deleted")
 }
@@ -1,7 +1,7 @@
 /*
  * File: routes.go
  * File Created: Sunday, 11th June 2023 9:57:15 pm
- * Last Modified: Friday, 23rd June 2023 3:29:31 pm
+ * Last Modified: Monday, 19th October 2026 2:15:44 pm
  * Author: Akhil Datla
  * Copyright © Akhil Datla 2023
  */
@@ -10,6 +10,8 @@ package server
 
 import (
 	"fmt"
+	"main/backend/organizations"
+	"net"
 	"net/http"
 	"path/filepath"
 
@@ -19,10 +21,36 @@ import (
 
 var e *echo.Echo
 
+const (
+	// adminKeyHeader is the request header carrying the admin key of an organization admin.
+	adminKeyHeader = "X-Admin-Key"
+
+	// organizationKey is the context key holding the ID of the organization a request acts for.
+	organizationKey = "organizationID"
+)
+
+// publicRoutes lists the routes devices call, which are open without an admin key.
+var publicRoutes = map[string]bool{
+	"/licenses/register":         true,
+	"/licenses/activate/offline": true,
+	"/licenses/deactivate":       true,
+	"/licenses/publickey":        true,
+	"/download":                  true,
+}
+
+// adminRoutes holds the method and path of every other route.
+var adminRoutes = make(map[string]bool)
+
+// TrustedProxies lists the address ranges of reverse proxies whose X-Forwarded-For header is
+// trusted for the client IP address. Without any, the address of the connection is used, so
+// clients cannot choose the address they are throttled under.
+var TrustedProxies []*net.IPNet
+
 // Start starts the server on the specified port, with optional logging.
 func Start(port int, log bool) {
 	e = echo.New()
 	e.HideBanner = true
+	e.IPExtractor = ipExtractor()
 
 	// Render GUI
 	e.Use(middleware.Gzip())
@@ -46,11 +74,59 @@ func Start(port int, log bool) {
 	}
 	e.Use(middleware.CORSWithConfig(defaultCORSConfig))
 
+	// Resolve the organization of admin requests
+	e.Use(authenticate)
+
 	initializeRoutes()
+	for _, route := range e.Routes() {
+		if !publicRoutes[route.Path] {
+			adminRoutes[route.Method+" "+route.Path] = true
+		}
+	}
 
 	e.Logger.Fatal(e.Start(fmt.Sprintf(":%d", port)))
 }
 
+// ipExtractor returns how the client IP address of a request is determined.
+func ipExtractor() echo.IPExtractor {
+	if len(TrustedProxies) == 0 {
+		return echo.ExtractIPDirect()
+	}
+
+	options := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	for _, proxy := range TrustedProxies {
+		options = append(options, echo.TrustIPRange(proxy))
+	}
+	return echo.ExtractIPFromXFFHeader(options...)
+}
+
+// authenticate resolves the admin key of a request to the organization it acts for. Until the
+// first admin is created, requests without an admin key act for the default organization.
+// Public routes and requests for the GUI are passed on as they are.
+func authenticate(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if !adminRoutes[c.Request().Method+" "+c.Path()] {
+			return next(c)
+		}
+
+		key := c.Request().Header.Get(adminKeyHeader)
+		if key == "" {
+			if organizations.KeysRequired() {
+				return c.String(http.StatusUnauthorized, "Admin key required")
+			}
+			c.Set(organizationKey, organizations.Default)
+			return next(c)
+		}
+
+		admin, err := organizations.Authenticate(key)
+		if err != nil {
+			return c.String(http.StatusUnauthorized, "Invalid admin key")
+		}
+		c.Set(organizationKey, admin.OrganizationID)
+		return next(c)
+	}
+}
+
 // initializeRoutes initializes all the routes and associates them with their respective handlers.
 func initializeRoutes() {
 	e.POST("/courses/create", createCourse)
@@ -58,8 +134,56 @@ func initializeRoutes() {
 	e.GET("/courses/all", getAllCourses)
 	e.POST("/courses/update", updateCourse)
 	e.DELETE("/courses/delete", deleteCourse)
+	e.POST("/courses/schedule", scheduleCourse)
+	e.GET("/courses/scan", scanCourse)
+	e.GET("/courses/revisions", getCourseRevisions)
+	e.POST("/courses/revisions/restore", restoreCourseRevision)
+	e.POST("/courses/import", importCourse)
+	e.POST("/courses/export/scorm", exportCourseSCORM)
+	e.GET("/courses/search", searchCourses)
+	e.POST("/courses/git", setCourseGitSource)
 	e.POST("/licenses/create", generateLicenses)
+	e.POST("/licenses/format", setLicenseKeyFormat)
 	e.POST("/licenses/register", registerLicense)
+	e.POST("/licenses/activate/offline", activateLicenseOffline)
 	e.DELETE("/licenses/revoke", revokeLicense)
+	e.GET("/licenses/seats", getLicenseSeats)
+	e.POST("/licenses/seats", setLicenseSeats)
+	e.POST("/licenses/deactivate", deactivateLicense)
+	e.GET("/licenses/publickey", getPublicKey)
+	e.GET("/licenses/failures", getRegistrationFailures)
+	e.DELETE("/licenses/lockouts", unlockRegistrations)
+	e.GET("/licenses/:key", getLicense)
+	e.GET("/batches/all", getAllBatches)
+	e.POST("/batches/export", exportBatch)
+	e.DELETE("/batches/revoke", revokeBatch)
+	e.POST("/bundles/create", createBundle)
+	e.GET("/bundles/all", getAllBundles)
+	e.POST("/bundles/courses/add", addBundleCourses)
+	e.DELETE("/bundles/courses/remove", removeBundleCourse)
+	e.DELETE("/bundles/delete", deleteBundle)
+	e.GET("/entitlements/info", getEntitlements)
+	e.POST("/entitlements/renew", renewEntitlements)
+	e.DELETE("/entitlements/delete", deleteEntitlement)
+	e.POST("/entitlements/transfer", transferEntitlements)
+	e.POST("/entitlements/revoke", revokeEntitlements)
+	e.GET("/entitlements/revoked", getRevokedEntitlements)
 	e.POST("/download", downloadCourses)
+	e.POST("/download/volumes", downloadCourseVolumes)
+	e.GET("/download/stale", getStaleDeliveries)
+	e.POST("/groups/create", createDeviceGroup)
+	e.GET("/groups/all", getAllDeviceGroups)
+	e.DELETE("/groups/delete", deleteDeviceGroup)
+	e.POST("/export", exportPackages)
+	e.POST("/branding/set", setBranding)
+	e.GET("/branding/info", getBranding)
+	e.DELETE("/branding/delete", deleteBranding)
+	e.POST("/organizations/create", createOrganization)
+	e.GET("/organizations/all", getAllOrganizations)
+	e.GET("/organizations/info", getOrganization)
+	e.POST("/organizations/update", updateOrganization)
+	e.DELETE("/organizations/delete", deleteOrganization)
+	e.POST("/organizations/admins/create", createAdmin)
+	e.GET("/organizations/admins/all", getAdmins)
+	e.DELETE("/organizations/admins/delete", deleteAdmin)
 }